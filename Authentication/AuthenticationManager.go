@@ -17,6 +17,9 @@ const (
 	email_index    = 2
 )
 
+// Credentials of the last successful sign up/sign in, used to restore the session after reconnecting
+var lastUser *User
+
 // function, argumentCount, arguments,
 
 // Handles the sign up request
@@ -29,9 +32,14 @@ func HandleSignup(commandArguments []string, socket *net.Conn) error {
 	if err != nil {
 		return &ClientErrors.JsonEncodeError{}
 	}
-	_, err = Requests.SendRequest(Requests.SignupRequest, request_data, socket) // Sends sign up request
+	err = authenticate(Requests.SignupRequest, request_data, socket) // Sends sign up request
+	if err != nil {
+		return err
+	}
 
-	return err
+	signedIn := Signin(user.Username, user.Password) // The account exists from now on, later sessions only sign in
+	lastUser = &signedIn
+	return nil
 
 }
 
@@ -45,7 +53,40 @@ func HandleSignIn(command_arguments []string, socket *net.Conn) error {
 	if err != nil {
 		return &ClientErrors.JsonEncodeError{}
 	}
-	_, err = Requests.SendRequest(Requests.LoginRequest, request_data, socket) // Sends sign in request
+	err = authenticate(Requests.LoginRequest, request_data, socket) // Sends sign in request
+	if err != nil {
+		return err
+	}
+
+	lastUser = &user
+	return nil
+}
+
+// Signs in again with the last used credentials on a new connection.
+// Does nothing if the client hasn't been authenticated yet.
+func Reauthenticate(socket *net.Conn) error {
+	if lastUser == nil {
+		return nil
+	}
+	request_data, err := json.Marshal(*lastUser)
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{Err: err}
+	}
+	return authenticate(Requests.LoginRequest, request_data, socket)
+}
 
-	return err
+// Forgets the last credentials, so the next connections aren't signed in
+func SignOut() {
+	lastUser = nil
+}
+
+// Sends a sign up/sign in request and saves the capabilities the server announces in its respone
+func authenticate(requestType Requests.RequestType, request_data []byte, socket *net.Conn) error {
+	var session Requests.SessionInfo
+	err := Requests.SendTypedRequest(requestType, request_data, socket, &session)
+	if err != nil {
+		return err
+	}
+	Requests.SetCapabilities(session.Capabilities)
+	return nil
 }
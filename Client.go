@@ -1,16 +1,66 @@
-package main
-
-import (
-	Menu "client/Menu"
-	"log"
-)
-
-func main() {
-	cli, err := Menu.NewCLI()
-	if err != nil { // If server connection fails
-		log.Fatal(err)
-	}
-
-	cli.PrintStartup()
-	cli.Loop()
-}
+package main
+
+import (
+	"client/Config"
+	Menu "client/Menu"
+	"log"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+const scriptFlag = "script"
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "clouddrive"
+	app.Usage = "CloudDrive command line interface"
+	app.UsageText = "clouddrive [global options] [command [arguments...]]"
+	app.Description = `Without a command, starts the interactive command line.
+   With a command, such as "clouddrive ls" or "clouddrive --profile work downloaddir Reports .",
+   signs in with the username and password of the config file, runs the command, waits for its transfers and exits.
+   With --script, runs the commands of the file the same way, "set -e" in it stops it at the first failing command.
+   Commands piped to it run like a script and exit with the same codes.
+   A watch command keeps running in the foreground until it's interrupted with Ctrl+C.
+   Exit codes: 0 success, 1 the command has failed, 2 wrong usage, 3 connection error, 4 authentication error, 5 a transfer has failed.`
+	app.HideHelp = true // "help" is a CloudDrive command, the usage is printed by --help
+	app.Flags = append(Config.Flags, cli.StringFlag{Name: scriptFlag, Usage: "run the commands of the script file, then wait for its transfers and exit"}, cli.HelpFlag)
+	app.Action = func(context *cli.Context) error {
+		if context.Bool("help") {
+			return cli.ShowAppHelp(context)
+		}
+		oneShot := context.NArg() > 0 || context.IsSet(scriptFlag) // The program exits after the command or the script
+		config, err := Config.FromContext(context)                 // Config file, environment variables and flags
+		if err != nil {
+			return err
+		}
+
+		menu, err := Menu.NewCLI(config)
+		if err != nil { // If server connection fails
+			if oneShot {
+				return cli.NewExitError(err.Error(), Menu.ExitCode(err))
+			}
+			return err
+		}
+
+		var code int
+		switch {
+		case context.IsSet(scriptFlag):
+			code = menu.RunScript(context.String(scriptFlag))
+		case oneShot:
+			code = menu.RunCommand(context.Args())
+		default:
+			menu.PrintStartup()
+			code = menu.Loop()
+		}
+		if code != Menu.ExitSuccess {
+			return cli.NewExitError("", code)
+		}
+		return nil
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
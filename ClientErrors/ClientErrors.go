@@ -10,11 +10,96 @@ type JsonDecodeError struct{ Err error }
 type FileNotExistError struct{ Filename string }
 type PathNotExistError struct{ Path string }
 type PathExistError struct{ Path string }
-type ReadFileInfoError struct{ Filename string }
+type ReadFileInfoError struct {
+	Filename string
+	Err      error
+}
 type ServerBadChunks struct{}
 type BadFileContent struct{ Filename string }
 type TimeOutRespone struct{}
 type ConvertToRelative struct{}
+type TLSConfigError struct{ Err error }
+type InvalidCommandError struct{ Command string }
+type TransferNotFoundError struct{ ID string }
+type FileChangedError struct{ Filename string }
+type JobNotFoundError struct{ ID string }
+type InvalidRateError struct{ Rate string }
+type InvalidPatternError struct{ Pattern string }
+type NotDirectoryError struct{ Path string }
+type UnterminatedQuoteError struct{ Quote rune }
+type MissingCredentialsError struct{ Command string }
+type JobsFailedError struct{ Count int }
+type UnsupportedFeatureError struct{ Feature string }
+type SyncRootMissingError struct{ Path string }
+
+type FileTooLargeError struct {
+	Filename string
+	Size     uint64
+}
+
+type InvalidScheduleError struct {
+	Field string // The field of the limit schedule entry
+	Value string
+}
+
+type JobStateError struct {
+	ID     int
+	State  string // The job's state, or its kind if it can't be stopped that way
+	Action string
+}
+
+type InvalidOptionError struct {
+	Option string
+	Value  string
+}
+
+type ResumeFileError struct {
+	Path string
+	Err  error
+}
+
+type SyncFileError struct {
+	Path string
+	Err  error
+}
+
+type MetadataError struct {
+	Path string
+	Err  error
+}
+
+type ShortTransferError struct {
+	Filename string
+	Expected int64
+	Received int64
+}
+
+type ChecksumMismatchError struct {
+	Filename string
+	Expected string // Digest computed by the sending side
+	Got      string // Digest computed by the receiving side
+}
+
+type ExtraTransferDataError struct {
+	Filename string
+	Expected int64
+}
+
+type UnexpectedResponeError struct {
+	Expected string // Name of the expected payload
+	Respone  string // Raw respone data
+	Err      error
+}
+
+type ConfigError struct {
+	Source string
+	Err    error
+}
+
+type CertificatePinError struct {
+	Expected string
+	Got      string
+}
 
 type CreateFolderError struct {
 	Foldername string
@@ -26,6 +111,17 @@ type CreateFileError struct {
 	Err      error
 }
 
+type SourceDepthError struct {
+	Path  string
+	Depth int
+}
+
+type ScriptError struct {
+	Path string
+	Line int
+	Err  error
+}
+
 type InvalidArgumentCountError struct {
 	Arguments uint8
 	Expected  uint8
@@ -35,14 +131,26 @@ func (error *ReciveDataError) Error() string {
 	return fmt.Sprintf("error when reciving a response from the server.\n%s", error.Err)
 }
 
+func (error *ReciveDataError) Unwrap() error {
+	return error.Err
+}
+
 func (error *SendDataError) Error() string {
 	return fmt.Sprintf("Error when attempting to send the data to the server.\n%s", error.Err)
 }
 
+func (error *SendDataError) Unwrap() error {
+	return error.Err
+}
+
 func (error *ServerConnectionError) Error() string {
 	return fmt.Sprintf("There has been an error connecting to the server.\nPlease check your connection and try again.\nIf it doesn't work contact the developers and send them this error message:\n\n%s", error.Err)
 }
 
+func (error *ServerConnectionError) Unwrap() error {
+	return error.Err
+}
+
 func (error *JsonDecodeError) Error() string {
 	return fmt.Sprintf("There has been an Error when attempting to decode the response from the server.\nPlease send this info to the developers:\n%s", error.Err)
 }
@@ -71,6 +179,10 @@ func (error *ReadFileInfoError) Error() string {
 	return fmt.Sprintf("Cannot read file %s info.", error.Filename)
 }
 
+func (error *ReadFileInfoError) Unwrap() error {
+	return error.Err
+}
+
 // Usage: Upload directory process
 func (error *ConvertToRelative) Error() string {
 	return "error converting path to relative.\nUploading process has stopped"
@@ -95,3 +207,142 @@ func (error *CreateFolderError) Error() string {
 func (error *CreateFileError) Error() string {
 	return fmt.Sprintf("Couldn't create file '%s'. The file won't be available.\nPlease provide the next error information to the developers:\n%s", error.Filename, error.Err)
 }
+
+func (error *TLSConfigError) Error() string {
+	return fmt.Sprintf("Couldn't load the TLS settings for the server connection.\n%s", error.Err)
+}
+
+func (error *CertificatePinError) Error() string {
+	return fmt.Sprintf("The server's certificate doesn't match the pinned certificate.\nExpected fingerprint %s, got %s", error.Expected, error.Got)
+}
+
+func (error *ConfigError) Error() string {
+	return fmt.Sprintf("Invalid configuration in %s:\n%s", error.Source, error.Err)
+}
+
+func (error *ConfigError) Unwrap() error {
+	return error.Err
+}
+
+func (error *InvalidCommandError) Error() string {
+	return fmt.Sprintf("Invalid command '%s'.\nPlease try a different command or use \"help\"", error.Command)
+}
+
+func (error *UnexpectedResponeError) Error() string {
+	return fmt.Sprintf("The server has sent an unexpected respone (expected %s): %s\nPlease send this info to the developers:\n%s", error.Expected, error.Err, error.Respone)
+}
+
+func (error *UnexpectedResponeError) Unwrap() error {
+	return error.Err
+}
+
+func (error *ShortTransferError) Error() string {
+	return fmt.Sprintf("Transfer of '%s' has ended after %d of %d bytes. The file is incomplete.", error.Filename, error.Received, error.Expected)
+}
+
+func (error *ExtraTransferDataError) Error() string {
+	return fmt.Sprintf("The server has sent more than the %d bytes of '%s'. The file can't be trusted.", error.Expected, error.Filename)
+}
+
+func (error *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("Checksum of '%s' doesn't match, the file has been corrupted in the transfer.\nSent SHA-256 %s, received SHA-256 %s", error.Filename, error.Expected, error.Got)
+}
+
+func (error *TransferNotFoundError) Error() string {
+	return fmt.Sprintf("There is no interrupted transfer '%s'. Type \"resume\" to list the interrupted transfers.", error.ID)
+}
+
+func (error *FileChangedError) Error() string {
+	return fmt.Sprintf("File '%s' has changed since its upload has started, please upload it again.", error.Filename)
+}
+
+func (error *ResumeFileError) Error() string {
+	return fmt.Sprintf("Error accessing the interrupted transfers file '%s': %v", error.Path, error.Err)
+}
+
+func (error *ResumeFileError) Unwrap() error {
+	return error.Err
+}
+
+func (error *SyncFileError) Error() string {
+	return fmt.Sprintf("Error accessing the sync state file '%s': %v", error.Path, error.Err)
+}
+
+func (error *SyncFileError) Unwrap() error {
+	return error.Err
+}
+
+func (error *InvalidOptionError) Error() string {
+	if error.Value == "" {
+		return fmt.Sprintf("Option --%s requires a value.", error.Option)
+	}
+	return fmt.Sprintf("Invalid value '%s' for option --%s.", error.Value, error.Option)
+}
+
+func (error *JobNotFoundError) Error() string {
+	return fmt.Sprintf("There is no job '%s'. Type \"jobs\" to list the jobs.", error.ID)
+}
+
+func (error *JobStateError) Error() string {
+	return fmt.Sprintf("Job %d can't be %s, it's %s.", error.ID, error.Action, error.State)
+}
+
+func (error *InvalidRateError) Error() string {
+	return fmt.Sprintf("Invalid rate '%s', expected bytes per second such as 500KB/s, 2MB/s or off.", error.Rate)
+}
+
+func (error *InvalidScheduleError) Error() string {
+	return fmt.Sprintf("Invalid %s '%s' in the limit schedule.", error.Field, error.Value)
+}
+
+func (error *FileTooLargeError) Error() string {
+	return fmt.Sprintf("'%s' is %d bytes, the server only supports up to 4 GiB. Please update the server to transfer it.", error.Filename, error.Size)
+}
+
+func (error *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("The server doesn't support %s. Please update the server to use it.", error.Feature)
+}
+
+func (error *SyncRootMissingError) Error() string {
+	return fmt.Sprintf("'%s' has been synced before but doesn't exist anymore, syncing it would delete its files in the cloud.\nPlease mount or restore the folder and sync again.", error.Path)
+}
+
+func (error *InvalidPatternError) Error() string {
+	return fmt.Sprintf("Invalid pattern '%s'.", error.Pattern)
+}
+
+func (error *NotDirectoryError) Error() string {
+	return fmt.Sprintf("'%s' is not a directory.", error.Path)
+}
+
+func (error *MetadataError) Error() string {
+	return fmt.Sprintf("Couldn't restore the modification time and permissions of '%s': %v", error.Path, error.Err)
+}
+
+func (error *MetadataError) Unwrap() error {
+	return error.Err
+}
+
+func (error *UnterminatedQuoteError) Error() string {
+	return fmt.Sprintf("The command has an unterminated %c quote.", error.Quote)
+}
+
+func (error *MissingCredentialsError) Error() string {
+	return fmt.Sprintf("'%s' needs an account, please set the username and password in the config file or in CLOUDDRIVE_USERNAME and CLOUDDRIVE_PASSWORD.", error.Command)
+}
+
+func (error *JobsFailedError) Error() string {
+	return fmt.Sprintf("%d of the transfers have failed.", error.Count)
+}
+
+func (error *ScriptError) Error() string {
+	return fmt.Sprintf("Script '%s' has stopped at line %d:\n%v", error.Path, error.Line, error.Err)
+}
+
+func (error *SourceDepthError) Error() string {
+	return fmt.Sprintf("Script '%s' hasn't been run, scripts can only source each other %d levels deep.", error.Path, error.Depth)
+}
+
+func (error *ScriptError) Unwrap() error {
+	return error.Err
+}
@@ -0,0 +1,71 @@
+package ClientErrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of server side errors, for errors.Is checks.
+// Every typed server error unwraps to one of these, and every error the server has sent unwraps to ErrServer.
+var (
+	ErrServer               = errors.New("server error")
+	ErrNotFound             = errors.New("not found")
+	ErrAlreadyExists        = errors.New("already exists")
+	ErrNotAuthenticated     = errors.New("not authenticated")
+	ErrAuthenticationFailed = errors.New("authentication failed")
+	ErrQuotaExceeded        = errors.New("quota exceeded")
+	ErrPermissionDenied     = errors.New("permission denied")
+	ErrInvalidRequest       = errors.New("invalid request")
+	ErrInternal             = errors.New("internal server error")
+)
+
+// Errors the server has rejected a request with, the message is the server's explanation
+type NotFoundError struct{ Message string }
+type AlreadyExistsError struct{ Message string }
+type NotAuthenticatedError struct{ Message string }
+type AuthenticationFailedError struct{ Message string }
+type QuotaExceededError struct{ Message string }
+type PermissionDeniedError struct{ Message string }
+type InvalidRequestError struct{ Message string }
+type InternalError struct{ Message string }
+
+// Error with a code the client doesn't know (or without a code, from older servers)
+type ServerError struct {
+	Code    int
+	Message string
+}
+
+func (err *NotFoundError) Error() string   { return err.Message }
+func (err *NotFoundError) Unwrap() []error { return []error{ErrNotFound, ErrServer} }
+
+func (err *AlreadyExistsError) Error() string   { return err.Message }
+func (err *AlreadyExistsError) Unwrap() []error { return []error{ErrAlreadyExists, ErrServer} }
+
+func (err *NotAuthenticatedError) Error() string   { return err.Message }
+func (err *NotAuthenticatedError) Unwrap() []error { return []error{ErrNotAuthenticated, ErrServer} }
+
+func (err *AuthenticationFailedError) Error() string { return err.Message }
+func (err *AuthenticationFailedError) Unwrap() []error {
+	return []error{ErrAuthenticationFailed, ErrServer}
+}
+
+func (err *QuotaExceededError) Error() string   { return err.Message }
+func (err *QuotaExceededError) Unwrap() []error { return []error{ErrQuotaExceeded, ErrServer} }
+
+func (err *PermissionDeniedError) Error() string   { return err.Message }
+func (err *PermissionDeniedError) Unwrap() []error { return []error{ErrPermissionDenied, ErrServer} }
+
+func (err *InvalidRequestError) Error() string   { return err.Message }
+func (err *InvalidRequestError) Unwrap() []error { return []error{ErrInvalidRequest, ErrServer} }
+
+func (err *InternalError) Error() string   { return err.Message }
+func (err *InternalError) Unwrap() []error { return []error{ErrInternal, ErrServer} }
+
+func (err *ServerError) Error() string {
+	if err.Code == 0 {
+		return err.Message
+	}
+	return fmt.Sprintf("%s (server error code %d)", err.Message, err.Code)
+}
+
+func (err *ServerError) Unwrap() error { return ErrServer }
@@ -0,0 +1,296 @@
+package Config
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const (
+	appDirName     = "clouddrive"
+	configFileName = "config.json"
+	envPrefix      = "CLOUDDRIVE_"
+
+	defaultServerAddr       = "clouddriveserver.duckdns.org:12345"
+	defaultTransmissionAddr = "clouddriveserver.duckdns.org:12346"
+	defaultDialTimeout      = 10 * time.Second
+	defaultResponseTimeout  = 10 * time.Second
+	defaultPrompt           = ">> "
+	defaultStreams          = 4
+)
+
+var (
+	errEmptyAddress = errors.New("the address must not be empty")
+	errBadTimeout   = errors.New("the timeout must be positive")
+	errBadStreams   = errors.New("the amount of streams must be between 1 and " + strconv.Itoa(Requests.MaxStreams))
+	errNoProfile    = errors.New("the profile isn't defined in the config file")
+	errOpenPassword = errors.New("the file holds a password that other users can read, run \"chmod 600\" on it or set CLOUDDRIVE_PASSWORD instead")
+)
+
+// Duration that is written as a string ("10s", "1m30s") in the config file
+type Duration struct{ time.Duration }
+
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(duration.String())
+}
+
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return err
+	}
+	duration.Duration, err = time.ParseDuration(text)
+	return err
+}
+
+// All the client settings.
+// Precedence (lowest to highest): defaults, config file, config file profile, CLOUDDRIVE_* environment variables, command-line flags.
+type Config struct {
+	ServerAddr       string        `json:"server_addr"`       // Address of the command socket
+	TransmissionAddr string        `json:"transmission_addr"` // Address of the file transmission sockets
+	DialTimeout      Duration      `json:"dial_timeout"`      // How long to wait for a connection to the server
+	ResponseTimeout  Duration      `json:"response_timeout"`  // How long to wait for data while transferring files
+	DownloadDir      string        `json:"download_dir"`      // Where downloads go when no local path is given
+	Prompt           string        `json:"prompt"`            // The prompt printed every command line
+	CAFile           string        `json:"ca_file"`           // PEM bundle of trusted certificate authorities
+	TLSPin           string        `json:"tls_pin"`           // SHA-256 fingerprint of the server's certificate
+	TLSInsecure      bool          `json:"tls_insecure"`      // Skip certificate verification (testing only)
+	Streams          int           `json:"streams"`           // Transmission sockets a large file is split to
+	Limit            string        `json:"limit"`             // Rate all the transfers share, such as "2MB/s"
+	LimitSchedule    []LimitWindow `json:"limit_schedule"`    // Times in which transfers are limited to a different rate
+	Username         string        `json:"username"`          // Account one-shot commands sign in with
+	Password         string        `json:"password"`          // CLOUDDRIVE_PASSWORD is preferred, a config file holding it must be private to its owner
+
+	Profiles map[string]json.RawMessage `json:"profiles"` // Named sets of settings that override the ones above, chosen with --profile
+}
+
+// A limit schedule entry, such as {"days": ["mon", "fri"], "from": "09:00", "to": "17:00", "limit": "1MB/s"}
+type LimitWindow struct {
+	Days  []string `json:"days"` // Every day if empty
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Limit string   `json:"limit"`
+}
+
+// Returns the settings used when nothing else has been configured
+func Default() Config {
+	return Config{
+		ServerAddr:       defaultServerAddr,
+		TransmissionAddr: defaultTransmissionAddr,
+		DialTimeout:      Duration{defaultDialTimeout},
+		ResponseTimeout:  Duration{defaultResponseTimeout},
+		Prompt:           defaultPrompt,
+		Streams:          defaultStreams,
+	}
+}
+
+// Returns the client's directory inside the user's config dir (XDG_CONFIG_HOME on Linux)
+func Dir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", &ClientErrors.ConfigError{Source: "config directory", Err: err}
+	}
+	return filepath.Join(configDir, appDirName), nil
+}
+
+// Returns the path of the default config file
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// Loads the settings from the given config file (default path when empty) on top of the defaults,
+// then the settings of the given profile (CLOUDDRIVE_PROFILE when empty) and the CLOUDDRIVE_* environment variables.
+// A missing config file is not an error, unless a profile has been asked for.
+// A config file that holds a password is refused if other users can read it.
+func Load(path string, profile string) (Config, error) {
+	config := Default()
+
+	explicitPath := path != "" || os.Getenv(envPrefix+"CONFIG") != ""
+	if path == "" {
+		path = os.Getenv(envPrefix + "CONFIG")
+	}
+	if path == "" {
+		defaultPath, err := DefaultPath()
+		if err != nil {
+			return Config{}, err
+		}
+		path = defaultPath
+	}
+
+	err := config.loadFile(path)
+	if err != nil && !(os.IsNotExist(err) && !explicitPath) { // Only the default config file may be missing
+		return Config{}, &ClientErrors.ConfigError{Source: path, Err: err}
+	}
+	if err == nil && config.holdsPassword() {
+		err = checkPrivate(path)
+		if err != nil {
+			return Config{}, &ClientErrors.ConfigError{Source: path, Err: err}
+		}
+	}
+
+	if profile == "" {
+		profile = os.Getenv(envPrefix + "PROFILE")
+	}
+	if profile != "" {
+		err = config.loadProfile(profile)
+		if err != nil {
+			return Config{}, &ClientErrors.ConfigError{Source: "profile " + profile, Err: err}
+		}
+	}
+
+	err = config.loadEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Returns whether the config file has set a password, in its settings or in one of its profiles
+func (config Config) holdsPassword() bool {
+	if config.Password != "" {
+		return true
+	}
+	for _, profile := range config.Profiles {
+		var settings struct {
+			Password string `json:"password"`
+		}
+		if json.Unmarshal(profile, &settings) == nil && settings.Password != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns an error if users other than the file's owner can read or write it.
+// Windows files don't have the permission bits, they are private to their user by default.
+func checkPrivate(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return errOpenPassword
+	}
+	return nil
+}
+
+// Overrides the settings with the fields that appear in the profile
+func (config *Config) loadProfile(name string) error {
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return errNoProfile
+	}
+	return json.Unmarshal(profile, config)
+}
+
+// Overrides the settings with the fields that appear in the config file
+func (config *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config) // Only the fields present in the file are overwritten
+}
+
+// Overrides the settings with the CLOUDDRIVE_* environment variables that are set
+func (config *Config) loadEnv() error {
+	stringFields := map[string]*string{
+		"SERVER":              &config.ServerAddr,
+		"TRANSMISSION_SERVER": &config.TransmissionAddr,
+		"DOWNLOAD_DIR":        &config.DownloadDir,
+		"PROMPT":              &config.Prompt,
+		"CA_FILE":             &config.CAFile,
+		"TLS_PIN":             &config.TLSPin,
+		"LIMIT":               &config.Limit,
+		"USERNAME":            &config.Username,
+		"PASSWORD":            &config.Password,
+	}
+	for name, field := range stringFields {
+		if value, ok := os.LookupEnv(envPrefix + name); ok {
+			*field = value
+		}
+	}
+
+	durations := map[string]*Duration{
+		"DIAL_TIMEOUT":     &config.DialTimeout,
+		"RESPONSE_TIMEOUT": &config.ResponseTimeout,
+	}
+	for name, field := range durations {
+		if value, ok := os.LookupEnv(envPrefix + name); ok {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return &ClientErrors.ConfigError{Source: envPrefix + name, Err: err}
+			}
+			field.Duration = duration
+		}
+	}
+
+	if value, ok := os.LookupEnv(envPrefix + "STREAMS"); ok {
+		streams, err := strconv.Atoi(value)
+		if err != nil {
+			return &ClientErrors.ConfigError{Source: envPrefix + "STREAMS", Err: err}
+		}
+		config.Streams = streams
+	}
+
+	if value, ok := os.LookupEnv(envPrefix + "TLS_INSECURE"); ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return &ClientErrors.ConfigError{Source: envPrefix + "TLS_INSECURE", Err: err}
+		}
+		config.TLSInsecure = insecure
+	}
+	return nil
+}
+
+// Checks that the settings can be used to connect to the server
+func (config Config) Validate() error {
+	if config.ServerAddr == "" {
+		return &ClientErrors.ConfigError{Source: "server_addr", Err: errEmptyAddress}
+	}
+	if config.TransmissionAddr == "" {
+		return &ClientErrors.ConfigError{Source: "transmission_addr", Err: errEmptyAddress}
+	}
+	if config.DialTimeout.Duration <= 0 {
+		return &ClientErrors.ConfigError{Source: "dial_timeout", Err: errBadTimeout}
+	}
+	if config.ResponseTimeout.Duration <= 0 {
+		return &ClientErrors.ConfigError{Source: "response_timeout", Err: errBadTimeout}
+	}
+	if config.Streams < 1 || config.Streams > Requests.MaxStreams {
+		return &ClientErrors.ConfigError{Source: "streams", Err: errBadStreams}
+	}
+	if _, err := Helper.ParseRate(config.Limit); err != nil {
+		return &ClientErrors.ConfigError{Source: "limit", Err: err}
+	}
+	_, err := config.RateWindows()
+	return err
+}
+
+// Returns the parsed limit schedule
+func (config Config) RateWindows() ([]Helper.RateWindow, error) {
+	windows := make([]Helper.RateWindow, 0, len(config.LimitSchedule))
+	for _, entry := range config.LimitSchedule {
+		window, err := Helper.ParseRateWindow(entry.Days, entry.From, entry.To, entry.Limit)
+		if err != nil {
+			return nil, &ClientErrors.ConfigError{Source: "limit_schedule", Err: err}
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
@@ -0,0 +1,121 @@
+package Config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+// Unsets the CLOUDDRIVE_* variables the tests rely on and hides the user's config file, they are restored when the test ends
+func clearEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range []string{"CONFIG", "PROFILE", "SERVER", "TRANSMISSION_SERVER", "PROMPT", "DOWNLOAD_DIR", "STREAMS"} {
+		t.Setenv(envPrefix+name, "")
+		os.Unsetenv(envPrefix + name)
+	}
+}
+
+// The settings the precedence test compares
+type settings struct {
+	ServerAddr, TransmissionAddr, Prompt, DownloadDir string
+	Streams                                           int
+}
+
+// Every layer sets one more setting than the layer above it, so each setting shows which layer won
+func TestPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	os.WriteFile(path, []byte(`{
+		"server_addr": "file:1", "transmission_addr": "file:2", "prompt": "file", "download_dir": "file", "streams": 2,
+		"profiles": {"work": {"transmission_addr": "profile:2", "prompt": "profile", "download_dir": "profile", "streams": 3}}
+	}`), 0o600)
+
+	tests := []struct {
+		name    string
+		path    string
+		profile string
+		env     map[string]string
+		flags   []string
+		want    settings
+	}{
+		{name: "defaults",
+			want: settings{ServerAddr: defaultServerAddr, TransmissionAddr: defaultTransmissionAddr, Prompt: defaultPrompt, Streams: defaultStreams}},
+		{name: "file", path: path,
+			want: settings{ServerAddr: "file:1", TransmissionAddr: "file:2", Prompt: "file", DownloadDir: "file", Streams: 2}},
+		{name: "profile", path: path, profile: "work",
+			want: settings{ServerAddr: "file:1", TransmissionAddr: "profile:2", Prompt: "profile", DownloadDir: "profile", Streams: 3}},
+		{name: "env", path: path, profile: "work", env: map[string]string{"PROMPT": "env", "DOWNLOAD_DIR": "env", "STREAMS": "5"},
+			want: settings{ServerAddr: "file:1", TransmissionAddr: "profile:2", Prompt: "env", DownloadDir: "env", Streams: 5}},
+		{name: "flags", path: path, profile: "work", env: map[string]string{"PROMPT": "env", "DOWNLOAD_DIR": "env", "STREAMS": "5"},
+			flags: []string{"--download-dir", "flag", "--streams", "6"},
+			want:  settings{ServerAddr: "file:1", TransmissionAddr: "profile:2", Prompt: "env", DownloadDir: "flag", Streams: 6}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range test.env {
+				t.Setenv(envPrefix+name, value)
+			}
+
+			var config Config
+			var err error
+			app := cli.NewApp()
+			app.Flags = Flags
+			app.Action = func(context *cli.Context) error {
+				config, err = FromContext(context)
+				return nil
+			}
+			args := append([]string{"clouddrive"}, test.flags...)
+			if test.path != "" {
+				args = append(args, "--config", test.path)
+			}
+			if test.profile != "" {
+				args = append(args, "--profile", test.profile)
+			}
+			if runErr := app.Run(args); runErr != nil {
+				t.Fatal(runErr)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := settings{config.ServerAddr, config.TransmissionAddr, config.Prompt, config.DownloadDir, config.Streams}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPasswordFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows files don't have permission bits")
+	}
+	t.Setenv(envPrefix+"PROFILE", "")
+	tests := []struct {
+		content string
+		profile string
+		mode    os.FileMode
+		refused bool
+	}{
+		{content: `{"username": "bob", "password": "secret"}`, mode: 0o600},
+		{content: `{"username": "bob", "password": "secret"}`, mode: 0o644, refused: true},
+		{content: `{"username": "bob", "password": "secret"}`, mode: 0o660, refused: true},
+		{content: `{"profiles": {"work": {"password": "secret"}}}`, profile: "work", mode: 0o604, refused: true},
+		{content: `{"profiles": {"work": {"password": "secret"}}}`, mode: 0o644, refused: true},
+		{content: `{"username": "bob"}`, mode: 0o644},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), configFileName)
+		os.WriteFile(path, []byte(test.content), test.mode)
+		os.Chmod(path, test.mode) // Not masked by the umask
+		_, err := Load(path, test.profile)
+		if refused := errors.Is(err, errOpenPassword); refused != test.refused || (!refused && err != nil) {
+			t.Errorf("%s with mode %o: %v", test.content, test.mode, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package Config
+
+import "github.com/urfave/cli"
+
+const (
+	configFlag  = "config"
+	profileFlag = "profile"
+)
+
+// Global command-line flags. Every flag overrides the config file and the environment variables.
+var Flags = []cli.Flag{
+	cli.StringFlag{Name: configFlag, Usage: "path of the config file (default: clouddrive/config.json in the user config directory)"},
+	cli.StringFlag{Name: profileFlag, Usage: "name of the config file profile to use, such as work"},
+	cli.StringFlag{Name: "server", Usage: "address of the command socket (host:port)"},
+	cli.StringFlag{Name: "transmission-server", Usage: "address of the file transmission socket (host:port)"},
+	cli.DurationFlag{Name: "dial-timeout", Usage: "how long to wait for a connection to the server"},
+	cli.DurationFlag{Name: "response-timeout", Usage: "how long to wait for data while transferring files"},
+	cli.StringFlag{Name: "download-dir", Usage: "default directory for downloads"},
+	cli.StringFlag{Name: "prompt", Usage: "the prompt printed every command line"},
+	cli.StringFlag{Name: "ca-file", Usage: "PEM bundle of trusted certificate authorities"},
+	cli.StringFlag{Name: "tls-pin", Usage: "SHA-256 fingerprint of the server's certificate"},
+	cli.BoolFlag{Name: "tls-insecure", Usage: "skip certificate verification (testing only)"},
+	cli.IntFlag{Name: "streams", Usage: "amount of transmission sockets a large file is split to"},
+	cli.StringFlag{Name: "limit", Usage: "rate all the transfers share, such as 2MB/s (default: unlimited)"},
+	cli.StringFlag{Name: "username", Usage: "account one-shot commands sign in with, the password is taken from CLOUDDRIVE_PASSWORD or the config file"},
+}
+
+// Loads the config file and the environment variables, then applies the flags that were given
+func FromContext(context *cli.Context) (Config, error) {
+	config, err := Load(context.GlobalString(configFlag), context.GlobalString(profileFlag))
+	if err != nil {
+		return Config{}, err
+	}
+
+	stringFields := map[string]*string{
+		"server":              &config.ServerAddr,
+		"transmission-server": &config.TransmissionAddr,
+		"download-dir":        &config.DownloadDir,
+		"prompt":              &config.Prompt,
+		"ca-file":             &config.CAFile,
+		"tls-pin":             &config.TLSPin,
+		"limit":               &config.Limit,
+		"username":            &config.Username,
+	}
+	for name, field := range stringFields {
+		if context.GlobalIsSet(name) {
+			*field = context.GlobalString(name)
+		}
+	}
+	if context.GlobalIsSet("dial-timeout") {
+		config.DialTimeout.Duration = context.GlobalDuration("dial-timeout")
+	}
+	if context.GlobalIsSet("response-timeout") {
+		config.ResponseTimeout.Duration = context.GlobalDuration("response-timeout")
+	}
+	if context.GlobalIsSet("streams") {
+		config.Streams = context.GlobalInt("streams")
+	}
+	if context.GlobalIsSet("tls-insecure") {
+		config.TLSInsecure = context.GlobalBool("tls-insecure")
+	}
+
+	return config, config.Validate()
+}
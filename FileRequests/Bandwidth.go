@@ -0,0 +1,228 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transfers are limited by token buckets around their transmission sockets.
+// All the transfers share the global bucket, which follows the limit schedule, and every job has a bucket of its own.
+
+const (
+	limitOption   = "limit"                // Command option that limits a single transfer
+	burstDuration = 100 * time.Millisecond // How many bytes a bucket holds, in time of its rate
+	minBurst      = 16 * 1024              // Smallest amount of bytes a bucket holds, so slow rates don't split every chunk
+)
+
+// Token bucket, a byte can be transferred for every token in it. The bucket fills at its rate up to its burst.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     int64               // Bytes per second, Helper.Unlimited if the rate isn't limited
+	schedule []Helper.RateWindow // Times in which the bucket fills at a different rate
+	tokens   float64             // Negative while the transfers owe tokens to the bucket
+	last     time.Time           // When the bucket has been filled last
+}
+
+var bandwidth = &rateLimiter{} // Shared by all the transfers
+
+// Sets the rate all the transfers share and the times in which it's different
+func SetBandwidthLimit(rate int64, schedule []Helper.RateWindow) {
+	bandwidth.mu.Lock()
+	defer bandwidth.mu.Unlock()
+	bandwidth.rate = rate
+	bandwidth.schedule = schedule
+}
+
+// Returns the rate of the bucket at the given time
+func (limiter *rateLimiter) rateAt(now time.Time) int64 {
+	for _, window := range limiter.schedule {
+		if window.Contains(now) {
+			return window.Rate
+		}
+	}
+	return limiter.rate
+}
+
+// Returns the rate outside the schedule
+func (limiter *rateLimiter) limit() int64 {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.rate
+}
+
+func (limiter *rateLimiter) setRate(rate int64) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.rate = rate
+}
+
+// Returns how many bytes may be transferred at once, 0 if the rate isn't limited
+func (limiter *rateLimiter) burst(now time.Time) int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return burstSize(limiter.rateAt(now))
+}
+
+func burstSize(rate int64) int {
+	if rate <= Helper.Unlimited {
+		return 0
+	}
+	return max(int(float64(rate)*burstDuration.Seconds()), minBurst)
+}
+
+// Takes tokens for bytes from the bucket. Returns how long to wait until the bucket has paid them back
+func (limiter *rateLimiter) reserve(bytes int, now time.Time) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	rate := limiter.rateAt(now)
+	if rate <= Helper.Unlimited { // Start with an empty bucket once the rate is limited again
+		limiter.tokens = 0
+		limiter.last = now
+		return 0
+	}
+
+	limiter.tokens = min(limiter.tokens+now.Sub(limiter.last).Seconds()*float64(rate), float64(burstSize(rate)))
+	limiter.last = now
+	limiter.tokens -= float64(bytes)
+	if limiter.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-limiter.tokens / float64(rate) * float64(time.Second))
+}
+
+// Socket that waits for the tokens of every byte it transfers, until its job is stopped
+type throttledConn struct {
+	net.Conn
+	ctx      context.Context
+	limiters []*rateLimiter
+}
+
+// Returns how many bytes may be transferred at once, at most size
+func (conn *throttledConn) pieceSize(size int) int {
+	now := time.Now()
+	for _, limiter := range conn.limiters {
+		if burst := limiter.burst(now); burst > 0 {
+			size = min(size, burst)
+		}
+	}
+	return size
+}
+
+// Waits until every bucket has paid back the tokens of the transferred bytes
+func (conn *throttledConn) wait(bytes int) error {
+	now := time.Now()
+	var delay time.Duration
+	for _, limiter := range conn.limiters {
+		delay = max(delay, limiter.reserve(bytes, now))
+	}
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-conn.ctx.Done():
+		return context.Cause(conn.ctx)
+	}
+}
+
+func (conn *throttledConn) Read(buffer []byte) (int, error) {
+	buffer = buffer[:conn.pieceSize(len(buffer))]
+	read, err := conn.Conn.Read(buffer)
+	if read > 0 {
+		if waitErr := conn.wait(read); err == nil {
+			err = waitErr
+		}
+	}
+	return read, err
+}
+
+func (conn *throttledConn) Write(buffer []byte) (int, error) {
+	written := 0
+	for written < len(buffer) {
+		piece := conn.pieceSize(len(buffer) - written)
+		err := conn.wait(piece)
+		if err != nil {
+			return written, err
+		}
+		sent, err := conn.Conn.Write(buffer[written : written+piece])
+		written += sent
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Removes the --limit option from the command arguments.
+// Returns the rate and whether the option has been given.
+func takeLimitOption(command_arguments []string) (int64, bool, []string, error) {
+	value, found, command_arguments, err := Helper.TakeOption(command_arguments, limitOption)
+	if err != nil || !found {
+		return Helper.Unlimited, false, command_arguments, err
+	}
+	rate, err := Helper.ParseRate(value)
+	if err != nil {
+		return Helper.Unlimited, false, command_arguments, &ClientErrors.InvalidOptionError{Option: limitOption, Value: value}
+	}
+	return rate, true, command_arguments, nil
+}
+
+// Handles limit command. Shows the limits, sets the global limit of this session or the limit of a running job
+func HandleLimit(command_arguments []string) (string, error) {
+	switch len(command_arguments) {
+	case 0:
+		return describeLimits(), nil
+	case 1: // limit <rate>
+		rate, err := Helper.ParseRate(command_arguments[0])
+		if err != nil {
+			return "", err
+		}
+		bandwidth.setRate(rate)
+		return fmt.Sprintf("Transfers are limited to %s\n", Helper.FormatRate(rate)), nil
+	case 2: // limit <job> <rate>
+		transfer, err := findJob(command_arguments[0])
+		if err != nil {
+			return "", err
+		}
+		rate, err := Helper.ParseRate(command_arguments[1])
+		if err != nil {
+			return "", err
+		}
+		if transfer.State() != jobRunning {
+			return "", &ClientErrors.JobStateError{ID: transfer.ID, State: transfer.State().String(), Action: "limited"}
+		}
+		transfer.limiter.setRate(rate)
+		return fmt.Sprintf("Job %d is limited to %s\n", transfer.ID, Helper.FormatRate(rate)), nil
+	}
+	return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: 2}
+}
+
+// Lists the global limit, the limit schedule and the limits of the running jobs
+func describeLimits() string {
+	now := time.Now()
+	bandwidth.mu.Lock()
+	current, rate, schedule := bandwidth.rateAt(now), bandwidth.rate, bandwidth.schedule
+	bandwidth.mu.Unlock()
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Current limit: %s\n", Helper.FormatRate(current))
+	fmt.Fprintf(&builder, "Outside the schedule: %s\n", Helper.FormatRate(rate))
+	for _, window := range schedule {
+		fmt.Fprintf(&builder, "Scheduled: %s\n", window)
+	}
+	for _, transfer := range sortedJobs() {
+		if jobRate := transfer.limiter.limit(); jobRate != Helper.Unlimited && transfer.State() == jobRunning {
+			fmt.Fprintf(&builder, "Job %d: %s\n", transfer.ID, Helper.FormatRate(jobRate))
+		}
+	}
+	return builder.String()
+}
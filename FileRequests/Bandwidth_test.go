@@ -0,0 +1,57 @@
+package FileRequestsManager
+
+import (
+	"client/Helper"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLimiterThroughput(t *testing.T) {
+	const sent = 2 * 1000 * 1000
+	rate, err := Helper.ParseRate("2MB/s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan int64)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- 0
+			return
+		}
+		defer conn.Close()
+		read, _ := io.Copy(io.Discard, conn)
+		received <- read
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &throttledConn{Conn: client, ctx: context.Background(), limiters: []*rateLimiter{{rate: rate}}}
+	started := time.Now()
+	chunk := make([]byte, 32*1024)
+	for written := 0; written < sent; written += len(chunk) {
+		if _, err := conn.Write(chunk[:min(len(chunk), sent-written)]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client.Close()
+	if read := <-received; read != sent {
+		t.Fatalf("received %d of %d bytes", read, sent)
+	}
+
+	// The bucket starts full, so the measured rate is a little over the limit
+	measured := float64(sent) / time.Since(started).Seconds()
+	if measured < float64(rate)*0.8 || measured > float64(rate)*1.3 {
+		t.Fatalf("limited to %d bytes per second, measured %.0f", rate, measured)
+	}
+}
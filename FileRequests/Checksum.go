@@ -0,0 +1,76 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+)
+
+// Sends the digest of the uploaded file and compares it with the digest of the bytes the server has received.
+// The server drops the file if the digests don't match.
+// Servers without ChecksumCapability don't answer, then the file can't be verified.
+func verifyUpload(socket *net.Conn, filename string, digest []byte) error {
+	if !Requests.Supports(Requests.ChecksumCapability) {
+		return nil
+	}
+	err := Helper.SetResponseDeadline(socket) // Don't wait forever for a server that doesn't answer
+	if err != nil {
+		return err
+	}
+	defer Helper.ClearDeadline(socket)
+
+	sent := Requests.FileDigest{Sha256: hex.EncodeToString(digest)}
+	data, err := json.Marshal(sent)
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{Err: err}
+	}
+
+	var received Requests.FileDigest
+	err = Requests.SendTypedRequest(Requests.FileChecksumRequest, data, socket, &received)
+	if err != nil {
+		return err
+	}
+	if received.Sha256 != sent.Sha256 {
+		return &ClientErrors.ChecksumMismatchError{Filename: filename, Expected: sent.Sha256, Got: received.Sha256}
+	}
+	return nil
+}
+
+// Compares the digest of the downloaded file with the one the server has sent with the end of the file.
+// Older servers don't send a digest, then the file can't be verified.
+func verifyDownload(endOfFile Requests.ResponeInfo, filename string, digest []byte) error {
+	if endOfFile.Text() == "" { // If the server hasn't sent a digest
+		return nil
+	}
+	var sent Requests.FileDigest
+	err := endOfFile.Decode(&sent)
+	if err != nil {
+		return err
+	}
+	received := hex.EncodeToString(digest)
+	if received != sent.Sha256 {
+		return &ClientErrors.ChecksumMismatchError{Filename: filename, Expected: sent.Sha256, Got: received}
+	}
+	return nil
+}
+
+// Returns the hex SHA-256 digest of a local file
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", &ClientErrors.ReadFileInfoError{Filename: path, Err: err}
+	}
+	defer file.Close()
+	digest := sha256.New()
+	_, err = io.Copy(digest, file)
+	if err != nil {
+		return "", &ClientErrors.ReadFileInfoError{Filename: path, Err: err}
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
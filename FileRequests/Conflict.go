@@ -0,0 +1,196 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const conflictOption = "on-conflict"
+
+// What a download does with a local file that already exists
+type conflictPolicy string
+
+const (
+	conflictRefuse    conflictPolicy = ""     // Without the option, downloads onto existing paths are refused
+	conflictSkip      conflictPolicy = "skip" // The local file is kept
+	conflictOverwrite conflictPolicy = "overwrite"
+	conflictRename    conflictPolicy = "rename" // The download is saved under a free name next to the local file
+	conflictNewer     conflictPolicy = "newer"  // The local file is overwritten only if the cloud file has been modified after it
+	conflictAsk       conflictPolicy = "ask"    // The user chooses to skip, overwrite or rename every file
+)
+
+var askUser func(question string) string // Prints the question and reads the user's answer, nil if no one can be asked
+
+// Sets how the user is asked about conflicts
+func SetPrompt(prompt func(question string) string) {
+	askUser = prompt
+}
+
+// Removes the --on-conflict option from the command arguments and returns its policy
+func takeConflictOption(command_arguments []string) (conflictPolicy, []string, error) {
+	value, found, command_arguments, err := Helper.TakeOption(command_arguments, conflictOption)
+	if err != nil || !found {
+		return conflictRefuse, command_arguments, err
+	}
+	switch policy := conflictPolicy(value); policy {
+	case conflictSkip, conflictOverwrite, conflictRename, conflictNewer, conflictAsk:
+		return policy, command_arguments, nil
+	}
+	return conflictRefuse, command_arguments, &ClientErrors.InvalidOptionError{Option: conflictOption, Value: value}
+}
+
+// Resolves the conflicts of a download with the existing local files, and keeps what it did for the summary
+type conflictResolver struct {
+	policy    conflictPolicy
+	decisions map[string]conflictPolicy // Skip, overwrite or rename of the local paths decided before the download
+	skipped   []string
+	renamed   []string
+}
+
+func newConflictResolver(policy conflictPolicy) *conflictResolver {
+	return &conflictResolver{policy: policy, decisions: make(map[string]conflictPolicy)}
+}
+
+// Returns whether the conflicts have to be decided by the cloud listing before the download
+func (resolver *conflictResolver) decidesFirst() bool {
+	return resolver.policy == conflictNewer || resolver.policy == conflictAsk
+}
+
+// Decides the conflicts of the cloud files with the local files before the download starts.
+// The newer policy needs the times of the cloud files and the user can't be asked while the download runs in the background.
+func (resolver *conflictResolver) prepare(entries []Requests.TreeEntry, localPath string) {
+	policy := resolver.policy // An answer for all the files changes the resolver's policy
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		path := filepath.Join(localPath, filepath.FromSlash(entry.Path))
+		fileInfo, err := os.Stat(path)
+		if err != nil { // No conflict
+			continue
+		}
+		switch {
+		case policy == conflictAsk:
+			resolver.decisions[path] = resolver.ask(path)
+		case entry.ModTime > fileInfo.ModTime().Unix():
+			resolver.decisions[path] = conflictOverwrite
+		default:
+			resolver.decisions[path] = conflictSkip
+		}
+	}
+}
+
+// Asks the user what to do with the existing file. A capital answer is used for the rest of the conflicts too
+func (resolver *conflictResolver) ask(path string) conflictPolicy {
+	if resolver.policy != conflictAsk { // The user has answered for all the files
+		return resolver.policy
+	}
+	if askUser == nil {
+		return conflictSkip
+	}
+	answers := map[string]conflictPolicy{"o": conflictOverwrite, "s": conflictSkip, "r": conflictRename}
+	for {
+		answer := strings.TrimSpace(askUser(fmt.Sprintf("%s already exists. [o]verwrite, [s]kip or [r]ename? (O/S/R for all) ", path)))
+		if answer == "" { // Nothing more can be read
+			return conflictSkip
+		}
+		if policy, found := answers[strings.ToLower(answer)]; found {
+			if answer != strings.ToLower(answer) {
+				resolver.policy = policy
+			}
+			return policy
+		}
+	}
+}
+
+// Returns the path the downloaded file is written to, or "" if the file isn't downloaded
+func (resolver *conflictResolver) target(path string) string {
+	fileInfo, err := os.Stat(path)
+	if err != nil { // No conflict
+		return path
+	}
+	decision, found := resolver.decisions[path]
+	if !found {
+		decision = resolver.policy
+		if decision == conflictNewer || decision == conflictAsk { // The file wasn't listed before the download
+			decision = conflictSkip
+		}
+	}
+	if fileInfo.IsDir() && decision == conflictOverwrite { // A folder isn't replaced by a file
+		decision = conflictSkip
+	}
+
+	switch decision {
+	case conflictSkip:
+		resolver.skipped = append(resolver.skipped, path)
+		return ""
+	case conflictRename:
+		newPath := freePath(path)
+		resolver.renamed = append(resolver.renamed, fmt.Sprintf("%s -> %s", path, newPath))
+		return newPath
+	}
+	return path
+}
+
+// Lists the files that have been skipped or renamed
+func (resolver *conflictResolver) summary() string {
+	var builder strings.Builder
+	if len(resolver.skipped) > 0 {
+		fmt.Fprintf(&builder, "Skipped existing files (%d):\n", len(resolver.skipped))
+		for _, path := range resolver.skipped {
+			builder.WriteString("\t" + path + "\n")
+		}
+	}
+	if len(resolver.renamed) > 0 {
+		fmt.Fprintf(&builder, "Renamed downloaded files (%d):\n", len(resolver.renamed))
+		for _, rename := range resolver.renamed {
+			builder.WriteString("\t" + rename + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// Prints the summary if files have been skipped or renamed, a nil resolver has nothing to print
+func (resolver *conflictResolver) printSummary() {
+	if resolver == nil {
+		return
+	}
+	if summary := resolver.summary(); summary != "" {
+		printLine("%s", summary)
+	}
+}
+
+// Returns the first "name (n).ext" path next to the given path that doesn't exist
+func freePath(path string) string {
+	extension := filepath.Ext(path)
+	base := strings.TrimSuffix(path, extension)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, extension)
+		if exists, err := Helper.IsPathExists(candidate); err != nil || !exists {
+			return candidate
+		}
+	}
+}
+
+// Returns the listing entry of the cloud file, by its parent folder's listing
+func requestFileEntry(cloudFile string, socket *net.Conn) (Requests.TreeEntry, error) {
+	cloudFile = cleanCloudPath(cloudFile)
+	separator := strings.LastIndex(cloudFile, "\\")
+	parent, name := cloudFile[:separator+1], cloudFile[separator+1:]
+	entries, err := requestTree(parent, socket)
+	if err != nil {
+		return Requests.TreeEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Path == name && !entry.IsDir {
+			return entry, nil
+		}
+	}
+	return Requests.TreeEntry{}, &ClientErrors.NotFoundError{Message: fmt.Sprintf("'%s' does not exist", cloudFile)}
+}
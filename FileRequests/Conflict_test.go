@@ -0,0 +1,64 @@
+package FileRequestsManager
+
+import (
+	"client/Requests"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAskSingleFileWithoutListing(t *testing.T) {
+	server, socket := startSession(t)
+	server.WriteFile("bob", "notes.txt", []byte("cloud notes"))
+	downloads := t.TempDir()
+	os.WriteFile(filepath.Join(downloads, "notes.txt"), []byte("local"), 0o644)
+	SetPrompt(func(string) string { return "r" })
+	t.Cleanup(func() { SetPrompt(nil) })
+
+	created := JobsCreated()
+	err := HandleDownloadFile([]string{"--on-conflict=ask", "notes.txt", downloads}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WaitForJobs(created)
+	for _, requestType := range server.Received() {
+		if requestType == Requests.TreeRequest {
+			t.Fatal("the cloud folder has been listed to ask about one file")
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(downloads, "notes (1).txt")); err != nil || string(data) != "cloud notes" {
+		t.Fatalf("renamed download %q: %v", data, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(downloads, "notes.txt")); string(data) != "local" {
+		t.Fatalf("the local file has been changed to %q", data)
+	}
+	job := createdJob(t, created, downloadJob)
+	if !strings.Contains(job.conflicts.summary(), "Renamed downloaded files (1)") {
+		t.Fatalf("the job's summary is %q", job.conflicts.summary())
+	}
+}
+
+func TestDirectoryConflictSummary(t *testing.T) {
+	server, socket := startSession(t)
+	server.MakeDir("bob", "Photos")
+	server.WriteFile("bob", "Photos/a.jpg", []byte("cloud a"))
+	server.WriteFile("bob", "Photos/b.jpg", []byte("cloud b"))
+	downloads := t.TempDir()
+	os.Mkdir(filepath.Join(downloads, "Photos"), 0o755)
+	os.WriteFile(filepath.Join(downloads, "Photos", "a.jpg"), []byte("local a"), 0o644)
+
+	created := JobsCreated()
+	_, err := HandleDownloadDir([]string{"--on-conflict=skip", "Photos", downloads}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WaitForJobs(created)
+	job := createdJob(t, created, downloadDirJob)
+	if !strings.Contains(job.conflicts.summary(), "Skipped existing files (1)") {
+		t.Fatalf("the job's summary is %q", job.conflicts.summary())
+	}
+	if data, _ := os.ReadFile(filepath.Join(downloads, "Photos", "b.jpg")); string(data) != "cloud b" {
+		t.Fatalf("merged download %q", data)
+	}
+}
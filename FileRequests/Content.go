@@ -3,27 +3,49 @@ package FileRequestsManager
 import (
 	"client/ClientErrors"
 	"client/Helper"
+	"client/Requests"
+	"fmt"
 	"io/fs"
 	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
 )
 
-const (
-	fileNameIndex = 3
-	pathIndex     = 7
-	sizeIndex     = 10
-)
-
 type content struct {
-	Name string `json:"name"` // File name (including its extension)
-	Path string `json:"path"` // File's path in the Cloud
-	Size uint32 `json:"size"` // File's size in bytes
+	Name    string `json:"name"`              // File name (including its extension)
+	Path    string `json:"path"`              // File's path in the Cloud
+	Size    uint64 `json:"size"`              // File's size in bytes
+	Offset  uint64 `json:"offset,omitempty"`  // Bytes the server already has of a resumed upload
+	Streams int    `json:"streams,omitempty"` // Transmission sockets the client asks to split the file to
+	Requests.Metadata
+}
+
+// Download file request, Offset is the amount of bytes already downloaded of a resumed download
+type downloadRequest struct {
+	Data    string `json:"Data"`
+	Offset  uint64 `json:"Offset,omitempty"`
+	Streams int    `json:"Streams,omitempty"` // Most transmission sockets the client accepts to split the file to
+}
+
+// Create folder request of a directory upload, with the folder's metadata
+type createFolderRequest struct {
+	Data string `json:"Data"`
+	Requests.Metadata
+}
+
+// Rename and move request. Data keeps the "'first' 'second'" form older servers split by the quotes,
+// Source and Destination carry the paths as they are, so names with quotes in them can be sent
+type pathPairRequest struct {
+	Data        string `json:"Data"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+}
+
+func newPathPairRequest(source string, destination string) pathPairRequest {
+	return pathPairRequest{Data: "'" + source + "' '" + destination + "'", Source: source, Destination: destination}
 }
 
 // Creates a new file struct with the given parameters
-func newContent(name string, path string, size uint32) content {
+func newContent(name string, path string, size uint64) content {
 	return content{
 		Name: name,
 		Path: path,
@@ -31,50 +53,49 @@ func newContent(name string, path string, size uint32) content {
 	}
 }
 
+// Checks that the file details sent by the server can be used to create the file locally
+func (file content) Validate() error {
+	if file.Name == "" || file.Name == "." || file.Name == ".." || strings.ContainsAny(file.Name, "/\\") {
+		return fmt.Errorf("invalid file name '%s'", file.Name)
+	}
+	return Requests.ValidateRelativePath(file.Path)
+}
+
 // Checks local file and returns the file api if exists
 func checkContent(filename string) (fs.FileInfo, error) {
-	clearPath := strings.Replace(filename, "'", "", Helper.RemoveAll) // Clears ' encloused chars if they exist
-	fileInfo, err := os.Stat(clearPath)                               // Check file (Access file path without enclosed quotation)
+	fileInfo, err := os.Stat(filename) // Check file
 	if err != nil {
 		if os.IsNotExist(err) { // If file not exists
-			return nil, &ClientErrors.FileNotExistError{Filename: clearPath}
+			return nil, &ClientErrors.FileNotExistError{Filename: filename}
 		} else {
-			return nil, &ClientErrors.ReadFileInfoError{Filename: clearPath}
+			return nil, &ClientErrors.ReadFileInfoError{Filename: filename, Err: err}
 		}
 	}
 	return fileInfo, nil
 }
 
-// Returns directory size
-func getDirSize(dirPath string) (uint32, error) {
+// Returns directory size, without the paths the rules exclude
+func getDirSize(dirPath string, rules Helper.IgnoreRules) (uint64, error) {
 	var totalSize int64
 	// Walk through all the files and dirctories in the given dir to calculate its size
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil { // If couldn't read file info
-			return &ClientErrors.ReadFileInfoError{Filename: info.Name()}
+	err := walkIncluded(dirPath, rules, func(path string, _ string, contentInfo fs.DirEntry) error {
+		if contentInfo.IsDir() {
+			return nil
 		}
-		if !info.IsDir() {
-			totalSize += info.Size() // Increase total size for files only
+		info, err := contentInfo.Info()
+		if err != nil { // If couldn't read file info
+			return &ClientErrors.ReadFileInfoError{Filename: contentInfo.Name(), Err: err}
 		}
+		totalSize += info.Size() // Increase total size for files only
 		return nil
 	})
-	return uint32(totalSize), err
+	return uint64(totalSize), err
 }
 
-// Parse json data request to content struct
-func ParseDataToContent(data string) (content, error) {
-	// Removing enclosing json field
-	data = data[1 : len(data)-1]
-	fields := strings.Split(data, "\"") // Split to type details
-
-	filename := fields[fileNameIndex]
-	path := fields[pathIndex]
-	convertSize, err := strconv.ParseUint((fields[sizeIndex])[1:], 10, 32) // Save file's size to uint
-	if err != nil {
-		return content{}, err
+// Checks that the server can hold the size, servers without 64-bit sizes only support up to 4 GiB
+func checkSize(path string, size uint64) error {
+	if !Requests.SizeSupported(size) {
+		return &ClientErrors.FileTooLargeError{Filename: path, Size: size}
 	}
-	fileSize := uint32(convertSize) // Convert to uint32
-
-	return newContent(filename, path, fileSize), nil
-
+	return nil
 }
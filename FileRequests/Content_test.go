@@ -0,0 +1,197 @@
+package FileRequestsManager
+
+import (
+	"bytes"
+	"client/Authentication"
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const largeSize = 5 << 30 // Past what 32 bits can hold
+
+// Creates a sparse file of largeSize bytes, it takes no space on the disk
+func sparseFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "disk.img")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	err = file.Truncate(largeSize)
+	if err != nil {
+		t.Skipf("can't create a sparse file: %v", err)
+	}
+	return path
+}
+
+func TestLargeFileSizeOnTheWire(t *testing.T) {
+	data, err := json.Marshal(newContent("disk.img", "", largeSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent struct{ Size uint64 }
+	json.Unmarshal(data, &sent)
+	if sent.Size != largeSize {
+		t.Fatalf("sent size %d of a %d bytes file", sent.Size, uint64(largeSize))
+	}
+
+	var grant Requests.ChunkGrant
+	err = json.Unmarshal([]byte(`{"ChunksSize":32768,"Size":5368709120,"Offset":4294967300}`), &grant)
+	if err == nil {
+		err = grant.Validate()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grant.Size != largeSize || grant.Offset <= math.MaxUint32 {
+		t.Fatalf("decoded size %d and offset %d", grant.Size, grant.Offset)
+	}
+}
+
+func TestUploadLargeFile(t *testing.T) {
+	path := sparseFile(t)
+	server, socket := startSession(t)
+
+	// The size reaches the server whole: a quota between 4 GiB and the file's size rejects it
+	server.SetQuota("bob", largeSize-(1<<29))
+	err := HandleUploadFile([]string{path}, socket)
+	if !errors.Is(err, ClientErrors.ErrQuotaExceeded) {
+		t.Fatalf("upload over the quota: %v", err)
+	}
+
+	// A server without LargeFilesCapability is never sent the file
+	server.SetLegacySizes(true)
+	err = Authentication.HandleSignIn([]string{"bob", "secret"}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Requests.Supports(Requests.LargeFilesCapability) {
+		t.Fatal("legacy server announced large files")
+	}
+	uploads := func() int {
+		count := 0
+		for _, requestType := range server.Received() {
+			if requestType == Requests.UploadFileRequest {
+				count++
+			}
+		}
+		return count
+	}
+	before := uploads()
+	var tooLarge *ClientErrors.FileTooLargeError
+	err = HandleUploadFile([]string{path}, socket)
+	if !errors.As(err, &tooLarge) || tooLarge.Size != largeSize {
+		t.Fatalf("upload to a legacy server: %v", err)
+	}
+	if uploads() != before {
+		t.Fatal("the upload request has been sent to a legacy server")
+	}
+}
+
+func TestUploadChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("notes that have grown"), 0o644)
+	tests := []struct {
+		size  int64
+		sent  int
+		grown bool
+	}{
+		{size: 5, sent: 5, grown: true}, // Nothing past the announced size is sent
+		{size: 64, sent: 21},
+	}
+	for _, test := range tests {
+		client, server := net.Pipe()
+		received := make(chan []byte)
+		go func() {
+			data, _ := io.ReadAll(server)
+			received <- data
+		}()
+		uploaded, err := uploadFile(test.size, 0, 4, path, false, client)
+		client.Close()
+		data := <-received
+		if len(data) != test.sent || uploaded != int64(test.sent) {
+			t.Errorf("size %d: sent %d bytes and counted %d, want %d", test.size, len(data), uploaded, test.sent)
+		}
+		var changed *ClientErrors.FileChangedError
+		var short *ClientErrors.ShortTransferError
+		if test.grown && !errors.As(err, &changed) || !test.grown && !errors.As(err, &short) {
+			t.Errorf("size %d: %v", test.size, err)
+		}
+	}
+}
+
+func TestDownloadLargeFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("reads a 5 GiB sparse file")
+	}
+	const tail = 4096
+	path := filepath.Join(t.TempDir(), "disk.img")
+	// The partial file of a download that has been interrupted past 4 GiB, it's truncated to the offset the download continues from
+	err := os.Rename(sparseFile(t), partialPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		server.Write(bytes.Repeat([]byte{7}, tail))
+		info, _ := Requests.BuildResponeInfo(Requests.ResponeType(Requests.StopTransmission), "")
+		data, _ := json.Marshal(info)
+		Helper.SendData(&server, data)
+	}()
+	received, err := downloadFile(path, 1024, largeSize, largeSize-tail, Requests.Metadata{}, true, &client)
+	if err != nil || received != largeSize {
+		t.Fatalf("downloaded %d of %d bytes: %v", received, int64(largeSize), err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.Size() != largeSize {
+		t.Fatalf("downloaded file holds %d bytes: %v", info.Size(), err)
+	}
+	last := make([]byte, tail)
+	_, err = file.ReadAt(last, largeSize-tail)
+	if err != nil || !bytes.Equal(last, bytes.Repeat([]byte{7}, tail)) {
+		t.Fatalf("the bytes past 4 GiB haven't been written at their offset: %v", err)
+	}
+}
+
+func TestLargeDirectorySize(t *testing.T) {
+	dir := filepath.Dir(sparseFile(t))
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644)
+	const total = largeSize + 5
+
+	size, err := getDirSize(dir, Helper.IgnoreRules{})
+	if err != nil || size != total {
+		t.Fatalf("directory size is %d, want %d: %v", size, uint64(total), err)
+	}
+
+	server, socket := startSession(t)
+	plan, err := planUploadDirectory(dir, "", Helper.IgnoreRules{})
+	if err != nil || !strings.Contains(plan, "total size: "+Helper.FormatSize(total)) {
+		t.Fatalf("dry run of the directory: %v\n%s", err, plan)
+	}
+
+	// The total reaches the server whole: a quota one byte short of it rejects the upload
+	server.SetQuota("bob", total-1)
+	_, err = HandleUploadDirectory([]string{dir}, socket)
+	if !errors.Is(err, ClientErrors.ErrQuotaExceeded) {
+		t.Fatalf("upload over the quota: %v", err)
+	}
+}
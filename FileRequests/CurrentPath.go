@@ -1,23 +1,36 @@
-package FileRequestsManager
-
-import "fmt"
-
-var (
-	CurrentPath string
-)
-
-func InitializeCurrentPath() {
-	CurrentPath = "Root:\\"
-}
-
-func PrintCurrentPath() {
-	fmt.Print(CurrentPath)
-}
-
-func IsCurrentPathInitialized() bool {
-	return CurrentPath != ""
-}
-
-func setCurrentPath(path string) {
-	CurrentPath = path
-}
+package FileRequestsManager
+
+import "fmt"
+
+const rootPrefix = "Root:" // Absolute cloud paths start with the root folder
+
+var (
+	CurrentPath string
+	downloadDir string // Local directory for downloads when no path is given
+)
+
+func InitializeCurrentPath() {
+	CurrentPath = "Root:\\"
+}
+
+// Forgets the current working directory, the prompt goes back to the signed out one
+func ResetCurrentPath() {
+	CurrentPath = ""
+}
+
+func PrintCurrentPath() {
+	fmt.Print(CurrentPath)
+}
+
+func IsCurrentPathInitialized() bool {
+	return CurrentPath != ""
+}
+
+func setCurrentPath(path string) {
+	CurrentPath = path
+}
+
+// Sets the local directory downloads are saved to when no path is given
+func SetDownloadDir(path string) {
+	downloadDir = path
+}
@@ -0,0 +1,123 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"fmt"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const dryRunOption = "dry-run" // Lists what a directory transfer would do instead of doing it
+
+// Folders and files a directory transfer would create, listed like the ls command
+type transferPlan struct {
+	lines   []string
+	folders int
+	files   int
+	size    int64
+}
+
+func (plan *transferPlan) addFolder(path string) {
+	plan.lines = append(plan.lines, "<DIR>\t"+path)
+	plan.folders++
+}
+
+func (plan *transferPlan) addFile(path string, size int64) {
+	plan.lines = append(plan.lines, Helper.FormatSize(size)+"\t"+path)
+	plan.files++
+	plan.size += size
+}
+
+// Returns the plan with the given title and its totals
+func (plan *transferPlan) describe(title string) string {
+	var builder strings.Builder
+	builder.WriteString(title + "\n")
+	for _, line := range plan.lines {
+		builder.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&builder, "Folders: %d, files: %d, total size: %s\n", plan.folders, plan.files, Helper.FormatSize(plan.size))
+	return builder.String()
+}
+
+// Lists the folders and files uploading the directory would create in the cloud folder, nothing is sent to the server
+func planUploadDirectory(dirPath string, cloudpath string, rules Helper.IgnoreRules) (string, error) {
+	target := strings.TrimSuffix(cleanCloudPath(absoluteCloudPath(cloudpath)), "\\") + "\\" + filepath.Base(dirPath)
+	var plan transferPlan
+	err := walkIncluded(dirPath, rules, func(contentPath string, relativePath string, contentInfo fs.DirEntry) error {
+		path := target
+		if relativePath != "." {
+			path += "\\" + strings.ReplaceAll(filepath.ToSlash(relativePath), "/", "\\")
+		}
+		if contentInfo.IsDir() {
+			plan.addFolder(path)
+			return nil
+		}
+		fileInfo, err := contentInfo.Info()
+		if err != nil {
+			return err
+		}
+		err = checkSize(contentPath, uint64(fileInfo.Size())) // The dry run fails like the upload would
+		if err != nil {
+			return err
+		}
+		plan.addFile(path, fileInfo.Size())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return plan.describe(fmt.Sprintf("Dry run, uploading %s would create:", dirPath)), nil
+}
+
+// Lists the folders and files downloading the cloud folder would create in the local folder, by the server's listing of it
+func planDownloadDirectory(dirname string, localPath string, socket *net.Conn) (string, error) {
+	entries, err := requestTree(dirname, socket)
+	if err != nil {
+		return "", err
+	}
+
+	var plan transferPlan
+	plan.addFolder(localPath)
+	for _, entry := range entries {
+		path := filepath.Join(localPath, filepath.FromSlash(entry.Path))
+		if entry.IsDir {
+			plan.addFolder(path)
+		} else {
+			plan.addFile(path, int64(entry.Size))
+		}
+	}
+	return plan.describe(fmt.Sprintf("Dry run, downloading %s would create:", cleanCloudPath(absoluteCloudPath(dirname)))), nil
+}
+
+// Returns the total size of the files of a tree listing
+func treeSize(entries []Requests.TreeEntry) int64 {
+	var size int64
+	for _, entry := range entries {
+		size += int64(entry.Size)
+	}
+	return size
+}
+
+// Requests the listing of every file and folder under the cloud folder, sorted by their paths.
+// Only servers with TreeCapability can list a whole tree.
+func requestTree(cloudpath string, socket *net.Conn) ([]Requests.TreeEntry, error) {
+	if !Requests.Supports(Requests.TreeCapability) {
+		return nil, &ClientErrors.UnsupportedFeatureError{Feature: "listing folder trees"}
+	}
+	data, err := Helper.ConvertStringToBytes(cloudpath)
+	if err != nil {
+		return nil, err
+	}
+	var listing Requests.TreeListing
+	err = Requests.SendTypedRequest(Requests.TreeRequest, data, socket, &listing)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(listing.Entries, func(i, j int) bool { return listing.Entries[i].Path < listing.Entries[j].Path })
+	return listing.Entries, nil
+}
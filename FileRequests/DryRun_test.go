@@ -0,0 +1,100 @@
+package FileRequestsManager
+
+import (
+	"client/Authentication"
+	"client/ClientErrors"
+	"client/Requests"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDryRunUploadDirectory(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.go": "package main", "src/lib.go": "package src", "app.log": "log"})
+	server, socket := startSession(t)
+	before := len(server.Received())
+
+	plan, err := HandleUploadDirectory([]string{"--dry-run", "--exclude", "*.log", dir}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"main.go", "src\\lib.go", "Folders: 2, files: 2"} {
+		if !strings.Contains(plan, line) {
+			t.Errorf("the plan doesn't list %q:\n%s", line, plan)
+		}
+	}
+	if strings.Contains(plan, "app.log") {
+		t.Errorf("the plan lists an excluded file:\n%s", plan)
+	}
+	if sent := server.Received()[before:]; len(sent) != 0 {
+		t.Fatalf("the dry run has sent requests %v", sent)
+	}
+	if server.Exists("bob", filepath.Base(dir)) {
+		t.Fatal("the dry run has created the cloud folder")
+	}
+}
+
+func TestDryRunDownloadDirectory(t *testing.T) {
+	server, socket := startSession(t)
+	server.MakeDir("bob", "Docs")
+	server.MakeDir("bob", "Docs/sub")
+	server.WriteFile("bob", "Docs/sub/b.txt", []byte("b"))
+	server.WriteFile("bob", "Docs/a.txt", []byte("a"))
+
+	entries, err := requestTree("Docs", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"a.txt", "sub", "sub/b.txt"}; !slices.Equal(paths, want) {
+		t.Fatalf("listed %q, want %q", paths, want)
+	}
+
+	local := t.TempDir()
+	before := len(server.Received())
+	plan, err := HandleDownloadDir([]string{"--dry-run", "Docs", local}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{filepath.Join(local, "Docs", "a.txt"), filepath.Join(local, "Docs", "sub", "b.txt"), "Folders: 2, files: 2"} {
+		if !strings.Contains(plan, line) {
+			t.Errorf("the plan doesn't list %q:\n%s", line, plan)
+		}
+	}
+	if sent := server.Received()[before:]; !slices.Equal(sent, []Requests.RequestType{Requests.TreeRequest}) {
+		t.Fatalf("the dry run has sent requests %v", sent)
+	}
+	if files, _ := os.ReadDir(local); len(files) != 0 {
+		t.Fatalf("the dry run has created %d local files", len(files))
+	}
+}
+
+func TestDryRunDownloadWithoutTree(t *testing.T) {
+	server, socket := startSession(t)
+	server.MakeDir("bob", "Docs")
+	server.SetLegacyTree(true)
+	err := Authentication.HandleSignIn([]string{"bob", "secret"}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := t.TempDir()
+	before := len(server.Received())
+	_, err = HandleDownloadDir([]string{"--dry-run", "Docs", local}, socket)
+	var unsupported *ClientErrors.UnsupportedFeatureError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("dry run without tree listings: %v", err)
+	}
+	if sent := server.Received()[before:]; len(sent) != 0 {
+		t.Fatalf("the dry run has sent requests %v", sent)
+	}
+	if files, _ := os.ReadDir(local); len(files) != 0 {
+		t.Fatalf("the dry run has created %d local files", len(files))
+	}
+}
@@ -1,419 +1,619 @@
-package FileRequestsManager
-
-import (
-	"client/ClientErrors"
-	"client/Helper"
-	"client/Requests"
-	"encoding/json"
-	"fmt"
-	"net"
-	"os"
-	"path/filepath"
-	"strings"
-)
-
-const (
-	// Command Indexes:
-	/////////////////////////
-	commandArgumentIndex     = 0
-	pathArgumentIndex        = 0
-	minimumArguments         = 1
-	operationArguments       = 2
-	oldFileName              = 0
-	newFileName              = 1
-	contentNameIndex         = 1
-	remove_argument          = 1
-	rename_arguments         = 2
-	move_arguments           = 2
-	showFolderArguments      = 1
-	minimumdownloadArguments = 1
-	localPathIndex           = 2
-	cloudPathIndex           = 2
-	/////////////////////////
-
-	path_index = 1
-
-	// Commands:
-	CreateFileCommand   = "newfile"
-	CreateFolderCommand = "newdir"
-)
-
-func convertResponeToPath(data string) string {
-	parts := strings.Split(data, "CurrentDirectory:")
-	return parts[path_index]
-}
-
-func HandleChangeDirectory(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < minimumArguments { // If argument was not given
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
-	}
-
-	data, err := Helper.ConvertStringToBytes(strings.Join(command_arguments, " "))
-	if err != nil {
-		return err
-	}
-	responeData, err := Requests.SendRequest(Requests.ChangeDirectoryRequest, data, socket)
-	if err != nil {
-		return err
-	}
-
-	path := convertResponeToPath(responeData)
-	setCurrentPath(path)
-	return nil
-}
-
-// Handle Garbage request
-func HandleGarbage(socket *net.Conn) error {
-	responeData, err := Requests.SendRequest(Requests.GarbageRequest, nil, socket) // Send request type without any data
-
-	if err != nil {
-		return err
-	}
-
-	path := convertResponeToPath(responeData)
-	setCurrentPath(path)
-	return nil
-}
-
-// Handle create content (file or directory) requests
-func HandleCreate(command []string, socket *net.Conn) error {
-	if len(command) < operationArguments {
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command)), Expected: uint8(operationArguments)}
-	}
-	data, err := Helper.ConvertStringToBytes(strings.Join(command[contentNameIndex:], " "))
-	if err != nil {
-		return err
-	}
-	var createType Requests.RequestType
-
-	switch command[commandArgumentIndex] {
-	case CreateFileCommand:
-		createType = Requests.CreateFileRequest
-	case CreateFolderCommand:
-		createType = Requests.CreateFolderRequest
-	default:
-		return fmt.Errorf("wrong create request")
-	}
-	_, err = Requests.SendRequest(createType, data, socket)
-	return err
-}
-
-// Handle Remove Content (File and Directory)
-func HandleRemoveContent(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < remove_argument {
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(remove_argument)}
-	}
-	data, err := Helper.ConvertStringToBytes(strings.Join(command_arguments[oldFileName:], " ")) // Convert content name to raw json bytes
-	if err != nil {
-		return err
-	}
-
-	_, err = Requests.SendRequest(Requests.DeleteContentRequest, data, socket)
-	return err
-}
-
-// Handle Rename request
-func HandleRename(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < rename_arguments { // If argument was not given
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(rename_arguments)}
-	}
-	var oldcontentName string
-	var newcontentName string
-	if Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) { // Check if the command arguments are enclosed within a quotation (') marks
-		oldcontentName = Helper.FindPath(command_arguments, Helper.FirstNameParameter, Helper.TwoCloudPaths)
-		newcontentName = fmt.Sprintf(" '" + Helper.FindPath(command_arguments, Helper.SecondNameParameter, Helper.TwoCloudPaths) + "'")
-	} else {
-		oldcontentName = fmt.Sprintf("'" + command_arguments[oldFileName] + "'")
-		newcontentName = fmt.Sprintf(" '" + command_arguments[newFileName] + "'")
-	}
-	paths := oldcontentName + newcontentName // Append to a string
-	data, err := Helper.ConvertStringToBytes(paths)
-	if err != nil {
-		return err
-	}
-	_, err = Requests.SendRequest(Requests.RenameRequest, data, socket)
-	return err
-}
-
-// Handle Move request
-func HandleMove(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < move_arguments {
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(move_arguments)}
-	}
-	var currentFilePath string
-	var newPath string
-	if Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) { // Check if the command arguments are enclosed within a quotation (') marks
-		currentFilePath = Helper.FindPath(command_arguments, Helper.FirstNameParameter, Helper.TwoCloudPaths)                    // Save the first path
-		newPath = fmt.Sprintf(" '" + Helper.FindPath(command_arguments, Helper.SecondNameParameter, Helper.TwoCloudPaths) + "'") // Save the second path
-	} else {
-		currentFilePath = fmt.Sprintf("'" + command_arguments[oldFileName] + "'")
-		newPath = fmt.Sprintf(" '" + command_arguments[newFileName] + "'")
-	}
-	paths := currentFilePath + newPath // Appened to one string
-	data, err := Helper.ConvertStringToBytes(paths)
-	if err != nil {
-		return err
-	}
-	_, err = Requests.SendRequest(Requests.MoveRequest, data, socket)
-	return err
-}
-
-// Handle ls command (List contents command)
-func HandleShow(command_arguments []string, socket *net.Conn) (string, error) {
-	if !(len(command_arguments) >= showFolderArguments || len(command_arguments) == 0) { // check for amount of arguments
-		return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
-	}
-	var data []byte
-	var err error
-	if len(command_arguments) >= showFolderArguments { // If specific path has been specified
-		data, err = Helper.ConvertStringToBytes(strings.Join(command_arguments[pathArgumentIndex:], " "))
-		if err != nil {
-			return "", err
-		}
-	} else {
-		data = nil // If path hasn't been specified
-	}
-	respone, err := Requests.SendRequest(Requests.ShowRequest, data, socket)
-	if err != nil {
-		return "", err
-	}
-	return respone, nil
-}
-
-// Handles upload file command
-func HandleUploadFile(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < minimumArguments { // If file name was not provided
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
-	}
-	var filename string
-	var cloudpath string
-	if Helper.IsQuoted(command_arguments, Helper.OneClosedPath) { // Check if the first command argument is enclosed within a quotation (') marks
-		filename = Helper.FindPath(command_arguments, Helper.FirstNameParameter, Helper.OneClosedPath) // Save the first path (filename to upload)
-		if Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) {                                  // Check if the second command argument is enclosed within a quotation (') marks
-			cloudpath = Helper.FindPath(command_arguments, Helper.SecondNameParameter, Helper.TwoCloudPaths) // Save the second path (path in cloud storage to save)
-		} else { // If first path is quoted but the second doesn't
-			cloudpath = Helper.ReturnNonQuotedSecondPath(command_arguments)
-		}
-	} else { // If command arguments are not enclosed within a quotation (') marks
-		// relay on argument indexes
-		filename = command_arguments[oldFileName]
-		if len(command_arguments) == cloudPathIndex { // If client specificed a path to save in cloud
-			cloudpath = command_arguments[newFileName]
-		}
-	}
-
-	fileInfo, err := checkContent(filename) // Check if file exists, if it does returns file info api
-	if err != nil {
-		return err
-	}
-	fileSize := uint32(fileInfo.Size())
-	file := newContent(filepath.Base(strings.Replace(filename, "'", "", Helper.RemoveAll)), cloudpath, fileSize) // Creates a new file struct for server communication
-	file_data, err := json.Marshal(file)
-	if err != nil {
-		return &ClientErrors.JsonEncodeError{}
-	}
-
-	respone, err := Requests.SendRequest(Requests.UploadFileRequest, file_data, socket) // Sends upload file request
-	if err != nil {                                                                     // If upload file request was rejected
-		return err
-	}
-	chunksSize, err := Helper.ConvertResponeToChunks(respone) // Convert respone to chunks size
-	if err != nil {                                           // If chunks size was returned from the server in a wrong type
-		return &ClientErrors.ServerBadChunks{} // Blame the server
-	}
-
-	// Creates a privte socket connection between the server to upload the file to the server
-	uploadSocket, err := Helper.CreatePrivateSocket()
-	if err != nil {
-		return err
-	}
-
-	go uploadFile(int64(fileSize), chunksSize, filename, true, *uploadSocket) // Upload the file with print reports
-
-	return nil
-}
-
-// Handles download file command
-func HandleDownloadFile(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < minimumdownloadArguments {
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
-	}
-	var filename string
-	var clientpath string
-	if Helper.IsQuoted(command_arguments, Helper.OneClosedPath) || Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) { // Check if the first or the second command argument is enclosed within a quotation (') marks
-		filename = Helper.FindPath(command_arguments, Helper.FirstNameParameter, Helper.OneClosedPath) // Save the first path (filename to upload)
-		filename = filename[Helper.SkipEnclose : len(filename)-Helper.SkipEnclose]                     // Remove enclouse chars
-		if Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) {                                  // Check if the second command argument is enclosed within a quotation (') marks
-			clientpath = Helper.FindPath(command_arguments, Helper.SecondNameParameter, Helper.TwoCloudPaths) // Save the second path (path in cloud storage to save)
-		} else { // If first path is quoted but the second doesn't
-			clientpath = Helper.ReturnNonQuotedSecondPath(command_arguments)
-		}
-	} else { // If command arguments are not enclosed within a quotation (') marks
-		// relay on argument indexes
-		filename = command_arguments[oldFileName]
-		if len(command_arguments) >= localPathIndex { // If local path has been specified
-			clientpath = command_arguments[newFileName]
-		}
-	}
-
-	// Checks if path exists
-	isExists, err := Helper.IsPathExists(clientpath)
-	if err != nil { // If check gone wrong
-		return err
-	}
-	if !isExists { // If path not exists
-		return &ClientErrors.PathNotExistError{Path: clientpath}
-	}
-
-	data, err := Helper.ConvertStringToBytes(filename) // Convert filename to json bytes
-	if err != nil {
-		return err
-	}
-
-	respone, err := Requests.SendRequest(Requests.DownloadFileRequest, data, socket) // Sends download file request
-	if err != nil {
-		return err
-	}
-
-	chunksSize, err := Helper.ConvertResponeToChunks(respone) // Convert respone to chunks size
-	if err != nil {                                           // If chunks size was returned from the server in a wrong type
-		return &ClientErrors.ServerBadChunks{} // Blame the server
-	}
-
-	fullPath := filepath.Join(clientpath, filepath.Base(filename)) // Creates the full path of the file to download
-
-	if chunksSize == 0 { // If chunksSize that has retruend is 0, meaning the file is empty only create it.
-		file, err := os.Create(fullPath) // Creates the file in the given/default path
-		if err != nil {
-			return &ClientErrors.CreateFileError{Filename: fullPath, Err: err}
-		}
-		file.Close()
-
-		fmt.Printf("File %s has been downloaded successfully\n", fullPath)
-		return nil
-	}
-
-	// Creates a privte socket connection between the server to download the file from the server
-	downloadSocket, err := Helper.CreatePrivateSocket()
-	if err != nil {
-		return err
-	}
-
-	go downloadFile(fullPath, chunksSize, false, downloadSocket) // Start downloading file process in a seprated goroutine with success print
-
-	return nil
-}
-
-// Handles upload directory command
-func HandleUploadDirectory(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < minimumArguments { // If dir name was not provided
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
-	}
-	var dirPath string
-	var cloudpath string
-	if Helper.IsQuoted(command_arguments, Helper.OneClosedPath) { // Check if the first command argument is enclosed within a quotation (') marks
-		dirPath = Helper.FindPath(command_arguments, Helper.FirstNameParameter, Helper.OneClosedPath) // Save the first path (filename to upload)
-		if Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) {                                 // Check if the second command argument is enclosed within a quotation (') marks
-			cloudpath = Helper.FindPath(command_arguments, Helper.SecondNameParameter, Helper.TwoCloudPaths) // Save the second path (path in cloud storage to save)
-		} else { // If first path is quoted but the second doesn't
-			cloudpath = Helper.ReturnNonQuotedSecondPath(command_arguments)
-		}
-	} else { // If command arguments are not enclosed within a quotation (') marks
-		// relay on argument indexes
-		dirPath = command_arguments[oldFileName]
-		if len(command_arguments) == cloudPathIndex { // If client specificed a path to save in cloud
-			cloudpath = command_arguments[newFileName]
-		}
-	}
-	_, err := checkContent(dirPath) // Checks if directory exists in local machine
-	if err != nil {
-		return err
-	}
-	pathSize, err := getDirSize(strings.Replace(dirPath, "'", "", Helper.RemoveAll))
-	if err != nil {
-		return err
-	}
-	dir := newContent(filepath.Base(strings.Replace(dirPath, "'", "", Helper.RemoveAll)), cloudpath, pathSize) // Creates a new dir struct for server communication
-	dir_data, err := json.Marshal(dir)
-	if err != nil {
-		return &ClientErrors.JsonEncodeError{}
-	}
-
-	_, err = Requests.SendRequest(Requests.UploadDirectoryRequest, dir_data, socket) // Sends upload folder request
-	if err != nil {                                                                  // If upload folder request was rejected
-		return err
-	}
-
-	// Creates a privte socket connection between the server to upload the file to the server
-	uploadSocket, err := Helper.CreatePrivateSocket()
-	if err != nil {
-		return err
-	}
-	go uploadDirectory(dirPath, *uploadSocket) // Start uploading directory process in a seprated goroutine
-
-	return nil
-}
-
-// Handles download directory command
-func HandleDownloadDir(command_arguments []string, socket *net.Conn) error {
-	if len(command_arguments) < minimumdownloadArguments {
-		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
-	}
-
-	var dirname string
-	var clientpath string
-
-	if Helper.IsQuoted(command_arguments, Helper.OneClosedPath) || Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) { // Check if the first or the second command argument is enclosed within a quotation (') marks
-		dirname = Helper.FindPath(command_arguments, Helper.FirstNameParameter, Helper.OneClosedPath) // Save the first path (filename to upload)
-		dirname = dirname[Helper.SkipEnclose : len(dirname)-Helper.SkipEnclose]                       // Remove enclouse chars
-		if Helper.IsQuoted(command_arguments, Helper.TwoCloudPaths) {                                 // Check if the second command argument is enclosed within a quotation (') marks
-			clientpath = Helper.FindPath(command_arguments, Helper.SecondNameParameter, Helper.TwoCloudPaths) // Save the second path (path in cloud storage to save)
-		} else { // If first path is quoted but the second doesn't
-			clientpath = Helper.ReturnNonQuotedSecondPath(command_arguments)
-		}
-	} else { // If command arguments are not enclosed within a quotation (') marks
-		// relay on argument indexes
-		dirname = command_arguments[oldFileName]
-		if len(command_arguments) >= localPathIndex { // If local path has been specified
-			clientpath = command_arguments[newFileName]
-		}
-	}
-
-	// Checks if path exists
-	isExists, err := Helper.IsPathExists(clientpath)
-	if err != nil { // If check gone wrong
-		return err
-	}
-	if !isExists { // If path not exists
-		return &ClientErrors.PathNotExistError{Path: clientpath}
-	}
-
-	// Checks if the directory to download is already exists in the client's PC
-	isExists, err = Helper.IsPathExists(filepath.Join(clientpath, dirname))
-	if err != nil {
-		return err
-	}
-	if isExists {
-		return &ClientErrors.PathExistError{Path: filepath.Join(clientpath, dirname)}
-	}
-
-	data, err := Helper.ConvertStringToBytes(dirname) // Convert filename to json bytes
-	if err != nil {
-		return err
-	}
-
-	_, err = Requests.SendRequest(Requests.DownloadDirRequest, data, socket) // Sends download directory request
-	if err != nil {                                                          // If download directory has been rejected
-		return err
-	}
-
-	// Creates a privte socket connection between the server to download the directory from the server (connects to the server)
-	downloadSocket, err := Helper.CreatePrivateSocket()
-	if err != nil {
-		return err
-	}
-
-	go downloadDirectory(filepath.Join(clientpath, filepath.Base(dirname)), *downloadSocket) // Start downloading directory process in a seprated goroutine
-
-	return nil
-}
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Command Indexes:
+	/////////////////////////
+	commandArgumentIndex     = 0
+	pathArgumentIndex        = 0
+	minimumArguments         = 1
+	operationArguments       = 2
+	oldFileName              = 0
+	newFileName              = 1
+	contentNameIndex         = 1
+	remove_argument          = 1
+	rename_arguments         = 2
+	move_arguments           = 2
+	showFolderArguments      = 1
+	minimumdownloadArguments = 1
+	localPathIndex           = 2
+	cloudPathIndex           = 2
+	/////////////////////////
+
+	// Commands:
+	CreateFileCommand   = "newfile"
+	CreateFolderCommand = "newdir"
+)
+
+func HandleChangeDirectory(command_arguments []string, socket *net.Conn) error {
+	if len(command_arguments) < minimumArguments { // If argument was not given
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+
+	data, err := Helper.ConvertStringToBytes(strings.Join(command_arguments, " "))
+	if err != nil {
+		return err
+	}
+	var respone Requests.ChangeDirResponse
+	err = Requests.SendTypedRequest(Requests.ChangeDirectoryRequest, data, socket, &respone)
+	if err != nil {
+		return err
+	}
+
+	setCurrentPath(respone.CurrentDirectory)
+	return nil
+}
+
+// Moves a new connection back to the current working directory, so the user keeps their place after reconnecting
+func RestoreCurrentPath(socket *net.Conn) error {
+	if !IsCurrentPathInitialized() { // If client hasn't authenticated yet
+		return nil
+	}
+	return HandleChangeDirectory([]string{CurrentPath}, socket)
+}
+
+// Handle Garbage request
+func HandleGarbage(socket *net.Conn) error {
+	var respone Requests.ChangeDirResponse
+	err := Requests.SendTypedRequest(Requests.GarbageRequest, nil, socket, &respone) // Send request type without any data
+	if err != nil {
+		return err
+	}
+
+	setCurrentPath(respone.CurrentDirectory)
+	return nil
+}
+
+// Handle create content (file or directory) requests
+func HandleCreate(command []string, socket *net.Conn) error {
+	if len(command) < operationArguments {
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command)), Expected: uint8(operationArguments)}
+	}
+	data, err := Helper.ConvertStringToBytes(strings.Join(command[contentNameIndex:], " "))
+	if err != nil {
+		return err
+	}
+	var createType Requests.RequestType
+
+	switch command[commandArgumentIndex] {
+	case CreateFileCommand:
+		createType = Requests.CreateFileRequest
+	case CreateFolderCommand:
+		createType = Requests.CreateFolderRequest
+	default:
+		return fmt.Errorf("wrong create request")
+	}
+	_, err = Requests.SendRequest(createType, data, socket)
+	return err
+}
+
+// Handle Remove Content (File and Directory)
+func HandleRemoveContent(command_arguments []string, socket *net.Conn) error {
+	if len(command_arguments) < remove_argument {
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(remove_argument)}
+	}
+	data, err := Helper.ConvertStringToBytes(strings.Join(command_arguments[oldFileName:], " ")) // Convert content name to raw json bytes
+	if err != nil {
+		return err
+	}
+
+	_, err = Requests.SendRequest(Requests.DeleteContentRequest, data, socket)
+	return err
+}
+
+// Handle Rename request
+func HandleRename(command_arguments []string, socket *net.Conn) error {
+	if len(command_arguments) < rename_arguments { // If argument was not given
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(rename_arguments)}
+	}
+	data, err := json.Marshal(newPathPairRequest(command_arguments[oldFileName], command_arguments[newFileName]))
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{}
+	}
+	_, err = Requests.SendRequest(Requests.RenameRequest, data, socket)
+	return err
+}
+
+// Handle Move request
+func HandleMove(command_arguments []string, socket *net.Conn) error {
+	if len(command_arguments) < move_arguments {
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(move_arguments)}
+	}
+	data, err := json.Marshal(newPathPairRequest(command_arguments[oldFileName], command_arguments[newFileName]))
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{}
+	}
+	_, err = Requests.SendRequest(Requests.MoveRequest, data, socket)
+	return err
+}
+
+// Handle ls command (List contents command)
+func HandleShow(command_arguments []string, socket *net.Conn) (string, error) {
+	if !(len(command_arguments) >= showFolderArguments || len(command_arguments) == 0) { // check for amount of arguments
+		return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+	var data []byte
+	var err error
+	if len(command_arguments) >= showFolderArguments { // If specific path has been specified
+		data, err = Helper.ConvertStringToBytes(strings.Join(command_arguments[pathArgumentIndex:], " "))
+		if err != nil {
+			return "", err
+		}
+	} else {
+		data = nil // If path hasn't been specified
+	}
+	respone, err := Requests.SendRequest(Requests.ShowRequest, data, socket)
+	if err != nil {
+		return "", err
+	}
+	return respone, nil
+}
+
+// Handles upload file command
+func HandleUploadFile(command_arguments []string, socket *net.Conn) error {
+	streamCount, command_arguments, err := takeStreamsOption(command_arguments)
+	if err != nil {
+		return err
+	}
+	rate, _, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return err
+	}
+	noMetadata, command_arguments := Helper.TakeFlag(command_arguments, noMetadataOption)
+	if len(command_arguments) < minimumArguments { // If file name was not provided
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+	filename := command_arguments[oldFileName]
+	var cloudpath string
+	if len(command_arguments) >= cloudPathIndex { // If client specificed a path to save in cloud
+		cloudpath = command_arguments[newFileName]
+	}
+
+	fileInfo, err := checkContent(filename) // Check if file exists, if it does returns file info api
+	if err != nil {
+		return err
+	}
+	record := resumeRecord{
+		Upload:     true,
+		LocalPath:  filename,
+		CloudPath:  absoluteCloudPath(cloudpath),
+		Size:       fileInfo.Size(),
+		Limit:      rate,
+		NoMetadata: noMetadata,
+	}
+	return startUpload(record, cloudpath, streamCount, socket)
+}
+
+// Requests to upload the file from the transfer's offset and uploads it on a seprated goroutine.
+// Large files are split to streamCount streams if the server supports it.
+// The transfer is saved until it has finished, so it can be resumed if it's interrupted.
+func startUpload(record resumeRecord, cloudpath string, streamCount int, socket *net.Conn) error {
+	err := checkSize(record.LocalPath, uint64(record.Size))
+	if err != nil {
+		return err
+	}
+	file := newContent(filepath.Base(record.LocalPath), cloudpath, uint64(record.Size)) // Creates a new file struct for server communication
+	file.Offset = uint64(record.Offset)
+	if fileInfo, err := os.Stat(record.LocalPath); err == nil && !record.NoMetadata {
+		file.Metadata = readMetadata(fileInfo)
+	}
+	if count := segmentCount(record.Size-record.Offset, streamCount); count > 1 {
+		file.Streams = count
+	}
+	file_data, err := json.Marshal(file)
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{}
+	}
+
+	var grant Requests.ChunkGrant
+	err = Requests.SendTypedRequest(Requests.UploadFileRequest, file_data, socket, &grant) // Sends upload file request
+	if err != nil {                                                                        // If upload file request was rejected or chunks size was returned from the server in a wrong type
+		return err
+	}
+	if int64(grant.Offset) > record.Offset { // The server can only continue from bytes it has been sent
+		return &ClientErrors.UnexpectedResponeError{Expected: "resume offset", Respone: fmt.Sprint(grant.Offset)}
+	}
+	record.Offset = int64(grant.Offset) // Continue from where the server asks, from the start if it doesn't support resuming
+	record, err = saveTransfer(record)
+	if err != nil {
+		return err
+	}
+	transfer, err := newJob(uploadJob, record.LocalPath, record.CloudPath, record.Size, &record, record.Limit)
+	if err != nil {
+		return err
+	}
+
+	if grant.Streams > 1 && grant.TransferID != "" { // If the server has accepted to split the file
+		transfer.run(func() (int64, error) {
+			started := time.Now()
+			sent, err := uploadSegments(transfer, record, grant)
+			if err == nil {
+				printLine("File %s has been uploaded successfully over %d streams (%s)\n", record.LocalPath, grant.Streams, formatThroughput(sent-record.Offset, time.Since(started)))
+			}
+			return sent, err
+		})
+		return nil
+	}
+
+	// Creates a privte socket connection between the server to upload the file to the server
+	uploadSocket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		transfer.finish(record.Offset, err)
+		return err
+	}
+
+	// Upload the file with print reports
+	transfer.run(func() (int64, error) {
+		defer (*uploadSocket).Close()
+		stop := transfer.attach(uploadSocket)
+		defer stop()
+		defer transfer.saveProgress()()
+		started := time.Now()
+		sent, err := uploadFile(record.Size, record.Offset, grant.ChunksSize, record.LocalPath, true, *uploadSocket)
+		if err == nil {
+			printLine("Average speed %s\n", formatThroughput(sent-record.Offset, time.Since(started)))
+		}
+		return sent, err
+	})
+
+	return nil
+}
+
+// Handles download file command
+func HandleDownloadFile(command_arguments []string, socket *net.Conn) error {
+	streamCount, command_arguments, err := takeStreamsOption(command_arguments)
+	if err != nil {
+		return err
+	}
+	rate, _, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return err
+	}
+	policy, command_arguments, err := takeConflictOption(command_arguments)
+	if err != nil {
+		return err
+	}
+	noMetadata, command_arguments := Helper.TakeFlag(command_arguments, noMetadataOption)
+	if len(command_arguments) < minimumdownloadArguments {
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+	filename := command_arguments[oldFileName]
+	var clientpath string
+	if len(command_arguments) >= localPathIndex { // If local path has been specified
+		clientpath = command_arguments[newFileName]
+	}
+
+	if clientpath == "" { // If local path hasn't been specified, use the configured download directory
+		clientpath = downloadDir
+	}
+
+	// Checks if path exists
+	isExists, err := Helper.IsPathExists(clientpath)
+	if err != nil { // If check gone wrong
+		return err
+	}
+	if !isExists { // If path not exists
+		return &ClientErrors.PathNotExistError{Path: clientpath}
+	}
+
+	localPath := filepath.Join(clientpath, filepath.Base(filename)) // Creates the full path of the file to download
+	isExists, err = Helper.IsPathExists(localPath)
+	if err != nil {
+		return err
+	}
+	var conflicts *conflictResolver
+	if isExists {
+		if policy == conflictRefuse {
+			return &ClientErrors.PathExistError{Path: localPath}
+		}
+		conflicts = newConflictResolver(policy)
+		switch policy {
+		case conflictNewer: // Only the cloud file's time is listed
+			entry, err := requestFileEntry(absoluteCloudPath(filename), socket)
+			if err != nil {
+				return err
+			}
+			entry.Path = filepath.Base(localPath)
+			conflicts.prepare([]Requests.TreeEntry{entry}, clientpath)
+		case conflictAsk: // Asked before the download runs in the background
+			conflicts.decisions[localPath] = conflicts.ask(localPath)
+		}
+		localPath = conflicts.target(localPath)
+		if localPath == "" { // Nothing is downloaded
+			conflicts.printSummary()
+			return nil
+		}
+	}
+
+	record := resumeRecord{
+		LocalPath:  localPath,
+		CloudPath:  absoluteCloudPath(filename),
+		Limit:      rate,
+		NoMetadata: noMetadata,
+	}
+	return startDownload(record, filename, streamCount, conflicts, socket)
+}
+
+// Requests to download the file from the transfer's offset and downloads it on a seprated goroutine.
+// The server splits large files to at most streamCount streams if it supports it.
+// The transfer is saved until it has finished, so it can be resumed if it's interrupted.
+// The conflicts resolver that has renamed the file is summarised when the download stops, it's nil if there was no conflict.
+func startDownload(record resumeRecord, filename string, streamCount int, conflicts *conflictResolver, socket *net.Conn) error {
+	request := downloadRequest{Data: filename, Offset: uint64(record.Offset)}
+	if streamCount > 1 {
+		request.Streams = streamCount
+	}
+	data, err := json.Marshal(request) // Convert filename to json bytes
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{Err: err}
+	}
+
+	var grant Requests.ChunkGrant
+	err = Requests.SendTypedRequest(Requests.DownloadFileRequest, data, socket, &grant) // Sends download file request
+	if err != nil {                                                                     // If download file request was rejected or chunks size was returned from the server in a wrong type
+		return err
+	}
+	err = grant.ValidateDownload()
+	if err != nil {
+		return &ClientErrors.UnexpectedResponeError{Expected: "download grant", Respone: fmt.Sprintf("%+v", grant), Err: err}
+	}
+	if int64(grant.Offset) > record.Offset { // The server can only continue from bytes that have been downloaded
+		return &ClientErrors.UnexpectedResponeError{Expected: "resume offset", Respone: fmt.Sprint(grant.Offset)}
+	}
+	record.Offset = int64(grant.Offset) // Continue from where the server sends, from the start if it doesn't support resuming
+	record.Size, err = grantedSize(grant, record.CloudPath, socket)
+	if err != nil {
+		return err
+	}
+	if record.NoMetadata {
+		grant.Metadata = Requests.Metadata{}
+	}
+
+	if grant.ChunksSize == 0 { // If the file is empty only create it, the server doesn't transmit empty files.
+		file, err := os.Create(record.LocalPath) // Creates the file in the given/default path
+		if err != nil {
+			return &ClientErrors.CreateFileError{Filename: record.LocalPath, Err: err}
+		}
+		file.Close()
+		tryRestoreMetadata(record.LocalPath, grant.Metadata)
+		if record.ID != 0 { // If an interrupted download of the file has been resumed
+			removeTransfer(record.ID)
+		}
+
+		printLine("File %s has been downloaded successfully\n", record.LocalPath)
+		conflicts.printSummary()
+		return nil
+	}
+
+	record, err = saveTransfer(record)
+	if err != nil {
+		return err
+	}
+	transfer, err := newJob(downloadJob, record.CloudPath, record.LocalPath, record.Size, &record, record.Limit)
+	if err != nil {
+		return err
+	}
+	transfer.conflicts = conflicts
+
+	if grant.Streams > 1 && grant.TransferID != "" { // If the server has split the file
+		transfer.run(func() (int64, error) {
+			started := time.Now()
+			received, err := downloadSegments(transfer, record, grant)
+			if err == nil {
+				printLine("File %s has been downloaded successfully over %d streams (%s)\n", record.LocalPath, grant.Streams, formatThroughput(received-record.Offset, time.Since(started)))
+			}
+			return received, err
+		})
+		return nil
+	}
+
+	// Creates a privte socket connection between the server to download the file from the server
+	downloadSocket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		transfer.finish(record.Offset, err)
+		return err
+	}
+
+	// Start downloading file process in a seprated goroutine with success print
+	transfer.run(func() (int64, error) {
+		defer (*downloadSocket).Close()
+		stop := transfer.attach(downloadSocket)
+		defer stop()
+		defer transfer.saveProgress()()
+		started := time.Now()
+		received, err := downloadFile(record.LocalPath, grant.ChunksSize, record.Size, record.Offset, grant.Metadata, false, downloadSocket)
+		if err == nil {
+			printLine("Average speed %s\n", formatThroughput(received-record.Offset, time.Since(started)))
+		}
+		return received, err
+	})
+
+	return nil
+}
+
+// Returns the size of the file the download grant is for.
+// Servers before typed payloads only grant the chunks size, the size is then taken from the listing of the file's folder.
+func grantedSize(grant Requests.ChunkGrant, cloudPath string, socket *net.Conn) (int64, error) {
+	if grant.ChunksSize == 0 || grant.Size > 0 {
+		return int64(grant.Size), nil
+	}
+	entry, err := requestFileEntry(cloudPath, socket)
+	if err != nil {
+		return 0, err
+	}
+	return int64(entry.Size), nil
+}
+
+// Handles upload directory command
+func HandleUploadDirectory(command_arguments []string, socket *net.Conn) (string, error) {
+	rate, _, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	rules, command_arguments, err := takeIgnoreOptions(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	dryRun, command_arguments := Helper.TakeFlag(command_arguments, dryRunOption)
+	noMetadata, command_arguments := Helper.TakeFlag(command_arguments, noMetadataOption)
+	if len(command_arguments) < minimumArguments { // If dir name was not provided
+		return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+	dirPath := command_arguments[oldFileName]
+	var cloudpath string
+	if len(command_arguments) >= cloudPathIndex { // If client specificed a path to save in cloud
+		cloudpath = command_arguments[newFileName]
+	}
+	dirInfo, err := checkContent(dirPath) // Checks if directory exists in local machine
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return planUploadDirectory(dirPath, cloudpath, rules)
+	}
+	pathSize, err := getDirSize(dirPath, rules)
+	if err != nil {
+		return "", err
+	}
+	err = checkSize(dirPath, pathSize)
+	if err != nil {
+		return "", err
+	}
+	dir := newContent(filepath.Base(dirPath), cloudpath, pathSize) // Creates a new dir struct for server communication
+	if !noMetadata {
+		dir.Metadata = readMetadata(dirInfo)
+	}
+	dir_data, err := json.Marshal(dir)
+	if err != nil {
+		return "", &ClientErrors.JsonEncodeError{}
+	}
+
+	_, err = Requests.SendRequest(Requests.UploadDirectoryRequest, dir_data, socket) // Sends upload folder request
+	if err != nil {                                                                  // If upload folder request was rejected
+		return "", err
+	}
+
+	// Creates a privte socket connection between the server to upload the file to the server
+	uploadSocket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		return "", err
+	}
+	transfer, err := newJob(uploadDirJob, dirPath, absoluteCloudPath(cloudpath), int64(pathSize), nil, rate)
+	if err != nil {
+		(*uploadSocket).Close()
+		return "", err
+	}
+	// Start uploading directory process in a seprated goroutine
+	transfer.run(func() (int64, error) {
+		defer (*uploadSocket).Close()
+		stop := transfer.attach(uploadSocket)
+		defer stop()
+		return 0, uploadDirectory(transfer, dirPath, rules, !noMetadata, *uploadSocket)
+	})
+
+	return "", nil
+}
+
+// Handles download directory command
+func HandleDownloadDir(command_arguments []string, socket *net.Conn) (string, error) {
+	rate, _, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	policy, command_arguments, err := takeConflictOption(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	dryRun, command_arguments := Helper.TakeFlag(command_arguments, dryRunOption)
+	noMetadata, command_arguments := Helper.TakeFlag(command_arguments, noMetadataOption)
+	if len(command_arguments) < minimumdownloadArguments {
+		return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+
+	dirname := command_arguments[oldFileName]
+	var clientpath string
+	if len(command_arguments) >= localPathIndex { // If local path has been specified
+		clientpath = command_arguments[newFileName]
+	}
+
+	if clientpath == "" { // If local path hasn't been specified, use the configured download directory
+		clientpath = downloadDir
+	}
+
+	// Checks if path exists
+	isExists, err := Helper.IsPathExists(clientpath)
+	if err != nil { // If check gone wrong
+		return "", err
+	}
+	if !isExists { // If path not exists
+		return "", &ClientErrors.PathNotExistError{Path: clientpath}
+	}
+
+	// Checks if the directory to download is already exists in the client's PC
+	localPath := filepath.Join(clientpath, filepath.Base(dirname))
+	conflicts := newConflictResolver(policy)
+	var entries []Requests.TreeEntry
+	folderInfo, err := os.Stat(localPath)
+	if err == nil { // The download is merged into the existing folder if a conflict policy has been given
+		if policy == conflictRefuse || !folderInfo.IsDir() {
+			return "", &ClientErrors.PathExistError{Path: localPath}
+		}
+		if conflicts.decidesFirst() && !dryRun {
+			entries, err = requestTree(dirname, socket)
+			if err != nil {
+				return "", err
+			}
+			conflicts.prepare(entries, localPath)
+		}
+	}
+	if dryRun {
+		return planDownloadDirectory(dirname, localPath, socket)
+	}
+	if entries == nil {
+		entries, _ = requestTree(dirname, socket) // The size only shows the job's progress, it stays unknown without a listing
+	}
+
+	data, err := Helper.ConvertStringToBytes(dirname) // Convert filename to json bytes
+	if err != nil {
+		return "", err
+	}
+
+	_, err = Requests.SendRequest(Requests.DownloadDirRequest, data, socket) // Sends download directory request
+	if err != nil {                                                          // If download directory has been rejected
+		return "", err
+	}
+
+	// Creates a privte socket connection between the server to download the directory from the server (connects to the server)
+	downloadSocket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		return "", err
+	}
+
+	transfer, err := newJob(downloadDirJob, absoluteCloudPath(dirname), localPath, treeSize(entries), nil, rate)
+	if err != nil {
+		(*downloadSocket).Close()
+		return "", err
+	}
+	transfer.conflicts = conflicts
+	// Start downloading directory process in a seprated goroutine
+	transfer.run(func() (int64, error) {
+		defer (*downloadSocket).Close()
+		stop := transfer.attach(downloadSocket)
+		defer stop()
+		return 0, downloadDirectory(transfer, localPath, conflicts, !noMetadata, *downloadSocket)
+	})
+
+	return "", nil
+}
@@ -1,337 +1,486 @@
-package FileRequestsManager
-
-import (
-	"bufio"
-	"client/ClientErrors"
-	"client/Helper"
-	"client/Requests"
-	"encoding/json"
-	"fmt"
-	"io"
-	"io/fs"
-	"net"
-	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
-)
-
-const (
-	empty    = 0
-	kilobyte = 1_000_000
-
-	firstIndex = 0
-	chunksSize = 11
-
-	stopTransmissionRespone = "{\"Type\":501,\"Data\":\"\"}"
-)
-
-//var mu sync.Mutex // Lock the file writing to make sure only one goroutine can write over the file
-
-// Uploads file to the cloud server with the given file size an the chunk from the cloud core technology, filename to open the file from local pc
-// shoutFlag to indicate whether to print upload finished or no
-func uploadFile(fileSize int64, chunksSize int, filename string, shoutFlag bool, socket net.Conn) {
-	file, err := os.Open(strings.Replace(filename, "'", "", Helper.RemoveAll)) // Open file
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-	defer file.Close()
-
-	chunk := make([]byte, chunksSize) // Save buffer of chunks
-
-	var validUpload = true
-	var totalBytesRead int64
-	var totalReadFlag int64 // Flag for client view to automatically update upload percentage and bar progress
-	var precentage int64
-
-	for {
-		bytesRead, err := file.Read(chunk)
-		if err == io.EOF { // If finish reading file succesfully
-			break
-		}
-		if err != nil { // If error occurred while reading the file
-			validUpload = false
-			fmt.Println(err.Error())
-			err = &ClientErrors.ReadFileInfoError{}
-			fmt.Println(err.Error())
-		}
-		if bytesRead == empty { // If finish reading file succesfully
-			break
-		}
-
-		_, err = socket.Write(chunk[:bytesRead]) // Sending chunk to server
-		if err != nil {                          // If sending error occured
-			validUpload = false
-			err = &ClientErrors.SendDataError{}
-			fmt.Println(err.Error())
-		}
-
-		// Add bytes read for the chunk to the flag percentage and to the total read bytes
-		totalReadFlag += int64(bytesRead)
-		totalBytesRead += int64(bytesRead)
-
-		if totalReadFlag >= kilobyte && shoutFlag { // If shout flag has been enabled, for every 1 Kilobyte update the progess and perecntage bar in the cli
-			totalReadFlag = 0
-			precentage = (totalBytesRead * 100) / fileSize // Calculates total read bytes compared to the total file size in percentages
-			printer := func(_ int, _ string) string {
-				var bar string
-				for i := 0; i < int(precentage/2); i++ {
-					bar += "-"
-				}
-				return bar
-			}
-			fmt.Printf("\033[F\033[K")
-			fmt.Printf("Upload Progress: %v%% - %s", precentage, printer(int(precentage), "-"))
-			fmt.Println()
-
-		}
-	}
-	// If upload finished and shout flag has been enabled
-	if validUpload && shoutFlag {
-		fmt.Printf("File %s has been uploaded successfully\n", filename)
-	}
-}
-
-// Upload directory to cloud server
-func uploadDirectory(dirpath string, socket net.Conn) {
-	err := filepath.WalkDir(dirpath, func(contentPath string, contentInfo fs.DirEntry, err error) error { // Walk through all the contents in the given dir path
-		if err != nil {
-			return err
-		}
-
-		relativePath, err := filepath.Rel(dirpath, contentPath) // Convert the contentPath absolute to relative from the given path to upload
-		if err != nil {
-			return &ClientErrors.ConvertToRelative{}
-		}
-		if relativePath != "." { // If path is not the base (already exists) path
-			dirData, err := Helper.ConvertStringToBytes(relativePath) // Convert new dir path to bytes
-			if err != nil {
-				return err
-			}
-
-			if !contentInfo.IsDir() { // If content is file
-
-				fileInfo, err := contentInfo.Info() // Get file's info
-				if err != nil {
-					return &ClientErrors.ReadFileInfoError{Filename: filepath.Base(relativePath)}
-				}
-
-				// Initializes file struct
-				file := newContent(filepath.Base(relativePath), filepath.Dir(relativePath), uint32(fileInfo.Size()))
-				// Convert file struct to json bytes
-				file_data, err := json.Marshal(file)
-				if err != nil {
-					return &ClientErrors.JsonEncodeError{Err: err}
-				}
-
-				// Sends Upload File reques
-				respone, err := Requests.SendRequest(Requests.UploadFileRequest, file_data, &socket)
-				if err != nil { // If upload file request was rejected
-					return err
-				}
-
-				chunksSize, err := Helper.ConvertResponeToChunks(respone) // Convert respone to chunks size
-				if err != nil {                                           // If chunks size was returned from the server in a wrong type
-					return &ClientErrors.ServerBadChunks{} // Blame the server
-				}
-
-				uploadFile(fileInfo.Size(), chunksSize, contentPath, false, socket) // Uploads the file with no prints
-
-			} else { // If content is directory
-				// Sends request to make a new directory
-				respone, err := Requests.SendRequestInfo(Requests.BuildRequestInfo(Requests.CreateFolderRequest, dirData), true, socket)
-				if err != nil {
-					return err
-				}
-
-				if respone.Type == Requests.ErrorRespone { // If respone is error
-					return fmt.Errorf(respone.Respone)
-				}
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		fmt.Println(err.Error())
-		return
-	}
-	_, err = Requests.SendRequestInfo(Requests.BuildRequestInfo(Requests.StopTransmission, nil), false, socket) // Send stop upload request to server
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-	fmt.Println("Upload directory has finished")
-
-}
-
-// Write the file content on a seprated goroutine to not waste time and resources for the main thread that recives the file
-// func writeFile(writer **bufio.Writer, chunkBytes []byte) {
-// 	fmt.Println("Locking... (Currently Unlocked)")
-// 	mu.Lock()         // Acquire lock before writing the file, promising that only one goroutine can write over the file
-// 	defer mu.Unlock() // Ensure the lock is released after writing the file
-// 	fmt.Println("It's locked")
-// 	_, err := (*writer).Write(chunkBytes)
-// 	if err != nil {
-// 		fmt.Println("Error writing data on the provided file path.\nPlease contact the developers")
-// 		return
-// 	}
-// 	fmt.Println("Unlocking... (Currently locked)")
-// }
-
-// TDL add file's size to the argument so it would print percentage bar
-// Download a file from the cloud server, prints finished downloading file if supression flag is off, prints errors in any case.
-func downloadFile(path string, chunksSize int, suppression bool, socket *net.Conn) {
-	file, err := os.Create(path) // Creates the file in the given/default path
-	if err != nil {
-		fmt.Println("Couldn't create the file in the provided path.\nPlease provide a different path.")
-		return
-	}
-	file.Close()
-
-	file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644) // Open file for writing
-	if err != nil {
-		fmt.Println("Couldn't open the file for writing data.\nPlease make sure you have provided a path with permissions for write.")
-		return
-	}
-	defer file.Close()
-
-	// Create a buffered writier for efficient writes
-	writer := bufio.NewWriter(file)
-
-	for {
-		chunkBytes, err := Helper.ReciveChunkData(socket, chunksSize)
-		// If the client hasn't recived any new chunks for over the configured timeout, finish reading file sucessfully
-		if netErr, ok := err.(*net.OpError); ok && netErr.Timeout() || string(chunkBytes) == stopTransmissionRespone {
-			break
-		}
-		if err != nil {
-			fmt.Println(err.Error())
-			return
-		}
-
-		_, err = (*writer).Write(chunkBytes)
-		if err != nil {
-			fmt.Println("Error writing data on the provided file path.\nPlease contact the developers")
-			return
-		}
-		//go writeFile(&writer, chunkBytes) // Write the file over goroutine to not interept the connection
-	}
-	err = writer.Flush() // Flush any remaining data in the buffer to the file
-	if err != nil {
-		fmt.Println("Error flushing data to the file.\nPlease contact the developers")
-		return
-	}
-	// If suppression flag is off, prints success
-	if !suppression {
-		fmt.Printf("File %s has been downloaded successfully\n", path)
-	}
-}
-
-func createFolder(info Requests.ResponeInfo, baseFolderPath string) error {
-	fullPath := filepath.Join(baseFolderPath, info.Respone) // Append the full path of the new directory by the base Folder path and the given folder path
-	err := os.Mkdir(fullPath, os.ModePerm)                  // Creates a folder
-	if err != nil {                                         // If creating folder was unsuccessfull
-		return &ClientErrors.CreateFolderError{Foldername: fullPath, Err: err}
-	}
-	return nil
-}
-
-// Implement getFileInfo for reciving folder implemention.
-// Returns all the file's info that is given from the server.
-// Input:
-// Requests.ResponeInfo - Server's Respone struct
-// baseFolderPath - Base path of client side
-// Output:
-// File's chunks size (If file's valid)
-// File's size (If file's valid)
-// Absolute filepath (if file's valid)
-// error (if file's not valid)
-func getFileInfo(socket *net.Conn, info Requests.ResponeInfo, baseFolderPath string) (uint32, uint32, string, error) {
-	content, err := ParseDataToContent(info.Respone) // Convert string json respone to content struct
-	if err != nil {
-		return empty, empty, "", err
-	}
-
-	absFilePath := filepath.Join(baseFolderPath, content.Path, content.Name) // Convert to absolute file path
-
-	dataBytes, err := Helper.ReciveData(socket) // Recieves chunks size bytes json data from server
-	if err != nil {
-		return empty, empty, "", err
-	}
-	responeInfo, err := Requests.GetResponseInfo(dataBytes) // Convert raw bytes json to ResponeInfo struct
-	if err != nil {
-		return empty, empty, "", err
-	}
-	if responeInfo.Type != Requests.ValidRespone { // If respone valid chunks hasn't recieved
-		return empty, empty, "", fmt.Errorf(responeInfo.Respone) // Returns error with its error data
-	}
-	chunks, err := strconv.ParseUint(responeInfo.Respone[chunksSize:], 10, 32)
-	if err != nil {
-		return empty, empty, "", err
-	}
-
-	return uint32(chunks), content.Size, absFilePath, nil
-}
-
-func downloadDirectory(path string, socket net.Conn) {
-	os.Mkdir(path, os.ModePerm) // Creates the base directory with set permissions for the directory
-
-	var startDownload = func() error {
-		// Start reciving contents in the base directory
-		for {
-			dataBytes, err := Helper.ReciveData(&socket) // Recieves bytes json data from server
-			if err != nil {
-				return err
-			}
-			responeInfo, err := Requests.GetResponseInfo(dataBytes) // Convert raw bytes json to ResponeInfo struct
-			if err != nil {
-				return err
-			}
-			// ResponeInfo is like RequestInfo, receiving RequestInfo types in ResponeInfo struct
-
-			switch responeInfo.Type {
-			case Requests.ResponeType(Requests.CreateFolderRequest):
-				// If server pointed at a directory to create
-				err = createFolder(responeInfo, path)
-				if err != nil {
-					fmt.Println(err.Error()) // Print create folder error, so it won't stop the reciving folder proccess
-				}
-			case Requests.ResponeType(Requests.DownloadFileRequest):
-				// If server pointed at a file to recieve
-				chunkSize, fileSize, fileAbsPath, err := getFileInfo(&socket, responeInfo, path) // Get all file's info by its ResponeInfo detail
-				if err != nil {
-					return err
-				}
-				// Avoid downloading empty file
-				if fileSize > 0 {
-					downloadFile(fileAbsPath, int(chunkSize), true, &socket) // Start downloading file proccess with no success prints
-				} else {
-					// If file is empty, only create it
-					file, err := os.Create(fileAbsPath) // Creates the file in the given/default path
-					if err != nil {
-						return &ClientErrors.CreateFileError{Filename: fileAbsPath, Err: err}
-					}
-					file.Close()
-				}
-
-			case Requests.ResponeType(Requests.StopTransmission): // If server indicated that the download proccess is finished
-				return nil
-			}
-
-		}
-	}
-	err := startDownload() // Start downloading proccess
-	if err != nil {
-		switch err := err.(type) {
-		case *net.OpError:
-			if err.Timeout() { // If error type is Timeout
-				fmt.Println("Downloading might have finished. Could not verify the download with the server.\nPlease make sure all the contents have been successfully downloaded.")
-			}
-		default: // If it's any other error type
-			fmt.Println(err.Error() + "\nDownload process has been stopped.")
-		}
-		return
-	}
-	fmt.Println("Finished Downloading " + path + " Path")
-}
+package FileRequestsManager
+
+import (
+	"bufio"
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+const (
+	empty = 0
+
+	maxEndOfFileSize = 64 * 1024 // Biggest end of file message the client expects after a file's bytes
+	partialSuffix    = ".part"   // Downloads are written to ".<name>.part" until they're complete
+)
+
+//var mu sync.Mutex // Lock the file writing to make sure only one goroutine can write over the file
+
+// Uploads file to the cloud server with the given file size an the chunk from the cloud core technology, filename to open the file from local pc
+// shoutFlag to indicate whether to print upload finished or no.
+// The upload starts at offset, the bytes before it have been uploaded already.
+// The file's SHA-256 digest is computed while sending and verified with the server at the end.
+// Returns the amount of the file's bytes that have been sent, including the offset.
+func uploadFile(fileSize int64, offset int64, chunksSize int, filename string, shoutFlag bool, socket net.Conn) (int64, error) {
+	file, err := os.Open(filename) // Open file
+	if err != nil {
+		return offset, &ClientErrors.FileNotExistError{Filename: filename}
+	}
+	defer file.Close()
+
+	chunk := make([]byte, chunksSize) // Save buffer of chunks
+	digest := sha256.New()            // Digest of every byte of the file
+
+	_, err = io.CopyN(digest, file, offset) // The digest covers the bytes that have been uploaded before too
+	if err != nil {
+		return offset, &ClientErrors.ReadFileInfoError{Filename: filename, Err: err}
+	}
+
+	reader := io.LimitReader(file, fileSize-offset) // Never send more than the size announced to the server, the following bytes would be read as the digest
+	totalBytesRead := offset
+	for {
+		bytesRead, err := reader.Read(chunk)
+		if err == io.EOF { // If finish reading file succesfully
+			break
+		}
+		if err != nil { // If error occurred while reading the file
+			return totalBytesRead, &ClientErrors.ReadFileInfoError{Filename: filename, Err: err}
+		}
+		if bytesRead == empty { // If finish reading file succesfully
+			break
+		}
+
+		_, err = socket.Write(chunk[:bytesRead]) // Sending chunk to server
+		if err != nil {                          // If sending error occured
+			return totalBytesRead, &ClientErrors.SendDataError{Err: err}
+		}
+		digest.Write(chunk[:bytesRead])
+
+		totalBytesRead += int64(bytesRead) // The job's progress is counted on the socket
+	}
+	if totalBytesRead != fileSize { // If the file has shrunk since its size was sent to the server
+		return totalBytesRead, &ClientErrors.ShortTransferError{Filename: filename, Expected: fileSize, Received: totalBytesRead}
+	}
+	if bytesRead, _ := file.Read(make([]byte, 1)); bytesRead > 0 { // If the file has grown since its size was sent to the server
+		return totalBytesRead, &ClientErrors.FileChangedError{Filename: filename}
+	}
+
+	err = verifyUpload(&socket, filename, digest.Sum(nil))
+	if err != nil {
+		return totalBytesRead, err
+	}
+	// If upload finished and shout flag has been enabled
+	if shoutFlag {
+		printLine("File %s has been uploaded successfully\n", filename)
+	}
+	return totalBytesRead, nil
+}
+
+// Upload directory to cloud server without the paths the rules exclude, every file is reported as the job's current file.
+// The modification times and permissions are sent with the files and the folders unless metadata is off.
+func uploadDirectory(transfer *transferJob, dirpath string, rules Helper.IgnoreRules, metadata bool, socket net.Conn) error {
+	err := walkIncluded(dirpath, rules, func(contentPath string, relativePath string, contentInfo fs.DirEntry) error { // Walk through all the contents in the given dir path
+		if relativePath != "." { // If path is not the base (already exists) path
+			fileInfo, err := contentInfo.Info() // Get content's info
+			if err != nil {
+				return &ClientErrors.ReadFileInfoError{Filename: filepath.Base(relativePath), Err: err}
+			}
+
+			if !contentInfo.IsDir() { // If content is file
+
+				// Initializes file struct
+				err = checkSize(contentPath, uint64(fileInfo.Size()))
+				if err != nil {
+					return err
+				}
+				file := newContent(filepath.Base(relativePath), filepath.Dir(relativePath), uint64(fileInfo.Size()))
+				if metadata {
+					file.Metadata = readMetadata(fileInfo)
+				}
+				// Convert file struct to json bytes
+				file_data, err := json.Marshal(file)
+				if err != nil {
+					return &ClientErrors.JsonEncodeError{Err: err}
+				}
+
+				// Sends Upload File reques
+				var grant Requests.ChunkGrant
+				err = Requests.SendTypedRequest(Requests.UploadFileRequest, file_data, &socket, &grant)
+				if err != nil { // If upload file request was rejected or chunks size was returned from the server in a wrong type
+					return err
+				}
+
+				transfer.startFile(relativePath, fileInfo.Size())
+				_, err = uploadFile(fileInfo.Size(), 0, grant.ChunksSize, contentPath, false, socket) // Uploads the file with no prints
+				if err != nil {
+					return err
+				}
+
+			} else { // If content is directory
+				request := createFolderRequest{Data: relativePath}
+				if metadata {
+					request.Metadata = readMetadata(fileInfo)
+				}
+				dirData, err := json.Marshal(request) // Convert new dir path to bytes
+				if err != nil {
+					return &ClientErrors.JsonEncodeError{Err: err}
+				}
+				// Sends request to make a new directory
+				respone, err := Requests.SendRequestInfo(Requests.BuildRequestInfo(Requests.CreateFolderRequest, dirData), true, socket)
+				if err != nil {
+					return err
+				}
+
+				if respone.Type == Requests.ErrorRespone { // If respone is error
+					return respone.Err()
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	_, err = Requests.SendRequestInfo(Requests.BuildRequestInfo(Requests.StopTransmission, nil), false, socket) // Send stop upload request to server
+	if err != nil {
+		return err
+	}
+	printLine("Upload directory has finished\n")
+	return nil
+}
+
+// Write the file content on a seprated goroutine to not waste time and resources for the main thread that recives the file
+// func writeFile(writer **bufio.Writer, chunkBytes []byte) {
+// 	fmt.Println("Locking... (Currently Unlocked)")
+// 	mu.Lock()         // Acquire lock before writing the file, promising that only one goroutine can write over the file
+// 	defer mu.Unlock() // Ensure the lock is released after writing the file
+// 	fmt.Println("It's locked")
+// 	_, err := (*writer).Write(chunkBytes)
+// 	if err != nil {
+// 		fmt.Println("Error writing data on the provided file path.\nPlease contact the developers")
+// 		return
+// 	}
+// 	fmt.Println("Unlocking... (Currently locked)")
+// }
+
+// TDL add file's size to the argument so it would print percentage bar
+// Download a file from the cloud server, prints finished downloading file if supression flag is off.
+// Reads exactly fileSize bytes and then the server's end of file message, so the socket can be reused for the next file.
+// The file is written to its partial file and moved to the path once it's complete and verified, a stopped download leaves the partial file.
+// The download starts at offset, the bytes before it are already in the partial file.
+// The metadata the server has sent is restored before the file is moved.
+// Returns the amount of the file's bytes that have been written, including the offset.
+func downloadFile(path string, chunksSize int, fileSize int64, offset int64, metadata Requests.Metadata, suppression bool, socket *net.Conn) (int64, error) {
+	if chunksSize <= 0 { // If the server hasn't granted chunks to receive the file with
+		return offset, &ClientErrors.ServerBadChunks{}
+	}
+	digest := sha256.New()                                       // Digest of every byte of the file
+	file, err := openDownload(partialPath(path), offset, digest) // Creates the partial file, or continues the existing one
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// Create a buffered writier for efficient writes
+	writer := bufio.NewWriter(file)
+	defer writer.Flush() // Keep the received bytes if the download stops, so it can be resumed
+
+	received, err := reciveFileBytes(socket, io.MultiWriter(writer, digest), path, chunksSize, offset, fileSize)
+	if err != nil {
+		return received, err
+	}
+	err = writer.Flush() // Flush any remaining data in the buffer to the file
+	if err != nil {
+		return received, &ClientErrors.CreateFileError{Filename: path, Err: err}
+	}
+
+	endOfFile, err := reciveEndOfFile(socket, path, fileSize)
+	if err != nil {
+		return received, err
+	}
+	err = verifyDownload(endOfFile, path, digest.Sum(nil))
+	if err != nil {
+		return received, err
+	}
+	err = commitDownload(file, path, fileSize, metadata)
+	if err != nil {
+		return received, err
+	}
+	// If suppression flag is off, prints success
+	if !suppression {
+		printLine("File %s has been downloaded successfully\n", path)
+	}
+	return received, nil
+}
+
+// Receives the file's bytes from received up to fileSize and writes them to the writer.
+// Returns the offset the file has been received up to.
+func reciveFileBytes(socket *net.Conn, writer io.Writer, path string, chunksSize int, received int64, fileSize int64) (int64, error) {
+	for received < fileSize {
+		// Never read past the end of the file, the following bytes belong to the next message
+		chunkBytes, err := Helper.ReciveChunkData(socket, int(min(int64(chunksSize), fileSize-received)))
+		if err != nil {
+			if Helper.IsConnectionLost(err) { // If the server closed the connection before sending the whole file
+				return received, &ClientErrors.ShortTransferError{Filename: path, Expected: fileSize, Received: received}
+			}
+			return received, err
+		}
+
+		_, err = writer.Write(chunkBytes)
+		if err != nil {
+			return received, &ClientErrors.CreateFileError{Filename: path, Err: err}
+		}
+		received += int64(len(chunkBytes))
+	}
+	return received, nil
+}
+
+// Returns the hidden file next to the path that a download is written to until it's complete
+func partialPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+partialSuffix)
+}
+
+// Moves the complete partial file of a download to the path, after its bytes are on the disk, its size is checked and its metadata is restored.
+// Closes the partial file.
+func commitDownload(file *os.File, path string, fileSize int64, metadata Requests.Metadata) error {
+	err := file.Sync()
+	var fileInfo os.FileInfo
+	if err == nil {
+		fileInfo, err = file.Stat()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return &ClientErrors.CreateFileError{Filename: file.Name(), Err: err}
+	}
+	if fileInfo.Size() < fileSize {
+		return &ClientErrors.ShortTransferError{Filename: path, Expected: fileSize, Received: fileInfo.Size()}
+	}
+	if fileInfo.Size() > fileSize {
+		return &ClientErrors.ExtraTransferDataError{Filename: path, Expected: fileSize}
+	}
+	tryRestoreMetadata(file.Name(), metadata)
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return &ClientErrors.CreateFileError{Filename: path, Err: err}
+	}
+	return nil
+}
+
+// Removes the partial file of a download that won't be continued
+func removePartial(path string) {
+	os.Remove(partialPath(path))
+}
+
+// Opens the file a download is written to. A resumed download keeps the first offset bytes of the existing file
+// and adds them to the digest, anything after them is dropped.
+func openDownload(path string, offset int64, digest io.Writer) (*os.File, error) {
+	if offset == 0 {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, &ClientErrors.CreateFileError{Filename: path, Err: err}
+		}
+		return file, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, &ClientErrors.CreateFileError{Filename: path, Err: err}
+	}
+	err = file.Truncate(offset)
+	if err == nil {
+		_, err = io.CopyN(digest, file, offset) // Leaves the file's position at the offset
+	}
+	if err != nil {
+		file.Close()
+		return nil, &ClientErrors.CreateFileError{Filename: path, Err: err}
+	}
+	return file, nil
+}
+
+// Receives the bytes of a file that isn't downloaded, so the rest of the stream can be read
+func discardFile(path string, chunksSize int, fileSize int64, socket *net.Conn) error {
+	if chunksSize <= 0 {
+		return &ClientErrors.ServerBadChunks{}
+	}
+	_, err := reciveFileBytes(socket, io.Discard, path, chunksSize, 0, fileSize)
+	if err != nil {
+		return err
+	}
+	_, err = reciveEndOfFile(socket, path, fileSize)
+	return err
+}
+
+// Recives the stop transmission message the server sends after the file's bytes, carrying the file's digest.
+// Anything else means the server has sent more bytes than the file's size.
+func reciveEndOfFile(socket *net.Conn, path string, fileSize int64) (Requests.ResponeInfo, error) {
+	err := Helper.SetResponseDeadline(socket)
+	if err != nil {
+		return Requests.ResponeInfo{}, err
+	}
+	defer Helper.ClearDeadline(socket)
+
+	dataBytes, err := Helper.ReciveDataLimited(socket, maxEndOfFileSize)
+	if err != nil {
+		if Helper.IsConnectionLost(err) {
+			return Requests.ResponeInfo{}, err
+		}
+		return Requests.ResponeInfo{}, &ClientErrors.ExtraTransferDataError{Filename: path, Expected: fileSize}
+	}
+	responeInfo, err := Requests.GetResponseInfo(dataBytes)
+	if err != nil || responeInfo.Type != Requests.ResponeType(Requests.StopTransmission) {
+		return Requests.ResponeInfo{}, &ClientErrors.ExtraTransferDataError{Filename: path, Expected: fileSize}
+	}
+	return responeInfo, nil
+}
+
+// Creates the folder the server has pointed at, returns it with the metadata the server has sent
+func createFolder(info Requests.ResponeInfo, baseFolderPath string) (restoredFolder, error) {
+	var folder Requests.FolderEntry
+	err := info.Decode(&folder) // Make sure the server can't create folders outside the downloaded folder
+	if err != nil {
+		return restoredFolder{}, err
+	}
+	fullPath := filepath.Join(baseFolderPath, folder.Path) // Append the full path of the new directory by the base Folder path and the given folder path
+	err = os.Mkdir(fullPath, os.ModePerm)                  // Creates a folder
+	if os.IsExist(err) {                                   // Downloads merged into an existing folder keep its folders
+		if folderInfo, statErr := os.Stat(fullPath); statErr == nil && folderInfo.IsDir() {
+			return restoredFolder{path: fullPath, metadata: folder.Metadata}, nil
+		}
+	}
+	if err != nil { // If creating folder was unsuccessfull
+		return restoredFolder{}, &ClientErrors.CreateFolderError{Foldername: fullPath, Err: err}
+	}
+	return restoredFolder{path: fullPath, metadata: folder.Metadata}, nil
+}
+
+// Implement getFileInfo for reciving folder implemention.
+// Returns all the file's info that is given from the server.
+// Input:
+// Requests.ResponeInfo - Server's Respone struct
+// baseFolderPath - Base path of client side
+// Output:
+// File's chunks size (If file's valid)
+// File's size (If file's valid)
+// Absolute filepath (if file's valid)
+// File's metadata (if the server has sent it)
+// error (if file's not valid)
+func getFileInfo(socket *net.Conn, info Requests.ResponeInfo, baseFolderPath string) (uint32, uint64, string, Requests.Metadata, error) {
+	var content content
+	err := info.Decode(&content) // Convert json respone to content struct
+	if err != nil {
+		return empty, empty, "", Requests.Metadata{}, err
+	}
+
+	absFilePath := filepath.Join(baseFolderPath, content.Path, content.Name) // Convert to absolute file path
+
+	dataBytes, err := Helper.ReciveData(socket) // Recieves chunks size bytes json data from server
+	if err != nil {
+		return empty, empty, "", Requests.Metadata{}, err
+	}
+	responeInfo, err := Requests.GetResponseInfo(dataBytes) // Convert raw bytes json to ResponeInfo struct
+	if err != nil {
+		return empty, empty, "", Requests.Metadata{}, err
+	}
+	if responeInfo.Type != Requests.ValidRespone { // If respone valid chunks hasn't recieved
+		return empty, empty, "", Requests.Metadata{}, responeInfo.Err() // Returns error with its error data
+	}
+	var grant Requests.ChunkGrant
+	err = responeInfo.Decode(&grant)
+	if err != nil {
+		return empty, empty, "", Requests.Metadata{}, err
+	}
+
+	return uint32(grant.ChunksSize), content.Size, absFilePath, content.Metadata, nil
+}
+
+// Download a directory from the cloud server, every file is reported as the job's current file.
+// Files that already exist are skipped, overwritten or renamed by the conflicts resolver, the job lists them when it stops.
+// The metadata the server sends is restored unless metadata is off, the folders' after all the files have been written.
+func downloadDirectory(transfer *transferJob, path string, conflicts *conflictResolver, metadata bool, socket net.Conn) error {
+	os.Mkdir(path, os.ModePerm) // Creates the base directory with set permissions for the directory
+	var folders []restoredFolder
+
+	var startDownload = func() error {
+		// Start reciving contents in the base directory
+		for {
+			dataBytes, err := Helper.ReciveData(&socket) // Recieves bytes json data from server
+			if err != nil {
+				return err
+			}
+			responeInfo, err := Requests.GetResponseInfo(dataBytes) // Convert raw bytes json to ResponeInfo struct
+			if err != nil {
+				return err
+			}
+			// ResponeInfo is like RequestInfo, receiving RequestInfo types in ResponeInfo struct
+
+			switch responeInfo.Type {
+			case Requests.ResponeType(Requests.CreateFolderRequest):
+				// If server pointed at a directory to create
+				folder, err := createFolder(responeInfo, path)
+				if err != nil {
+					printLine("%s\n", err.Error()) // Print create folder error, so it won't stop the reciving folder proccess
+				} else if metadata {
+					folders = append(folders, folder)
+				}
+			case Requests.ResponeType(Requests.DownloadFileRequest):
+				// If server pointed at a file to recieve
+				chunkSize, fileSize, fileAbsPath, fileMetadata, err := getFileInfo(&socket, responeInfo, path) // Get all file's info by its ResponeInfo detail
+				if err != nil {
+					return err
+				}
+				if !metadata {
+					fileMetadata = Requests.Metadata{}
+				}
+				target := conflicts.target(fileAbsPath) // Empty if the existing file is kept
+				// Avoid downloading empty file
+				if fileSize > 0 {
+					relativePath, _ := filepath.Rel(path, fileAbsPath)
+					transfer.startFile(relativePath, int64(fileSize))
+					if target == "" { // The server sends the file anyway
+						err = discardFile(fileAbsPath, int(chunkSize), int64(fileSize), &socket)
+					} else {
+						_, err = downloadFile(target, int(chunkSize), int64(fileSize), 0, fileMetadata, true, &socket) // Start downloading file proccess with no success prints
+						if err != nil {
+							removePartial(target)
+						}
+					}
+					if err != nil { // The rest of the stream can't be trusted after a bad file
+						return err
+					}
+				} else if target != "" {
+					// If file is empty, only create it
+					file, err := os.Create(target) // Creates the file in the given/default path
+					if err != nil {
+						return &ClientErrors.CreateFileError{Filename: target, Err: err}
+					}
+					file.Close()
+					tryRestoreMetadata(target, fileMetadata)
+				}
+
+			case Requests.ResponeType(Requests.StopTransmission): // If server indicated that the download proccess is finished
+				return nil
+			}
+
+		}
+	}
+	err := startDownload() // Start downloading proccess
+	restoreFolders(folders)
+	if err != nil {
+		return fmt.Errorf("%w\nDownload process has been stopped.", err)
+	}
+	printLine("Finished Downloading %s Path\n", path)
+	return nil
+}
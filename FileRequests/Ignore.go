@@ -0,0 +1,92 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ignoreFileName = ".cdignore" // Gitignore-style patterns of the paths under its directory that aren't uploaded
+	excludeOption  = "exclude"
+	includeOption  = "include"
+)
+
+// Removes the --exclude and --include options from the command arguments and returns their rules
+func takeIgnoreOptions(command_arguments []string) (Helper.IgnoreRules, []string, error) {
+	var rules Helper.IgnoreRules
+	excludes, command_arguments, err := Helper.TakeAllOptions(command_arguments, excludeOption)
+	if err != nil {
+		return rules, command_arguments, err
+	}
+	includes, command_arguments, err := Helper.TakeAllOptions(command_arguments, includeOption)
+	if err != nil {
+		return rules, command_arguments, err
+	}
+
+	for _, pattern := range excludes {
+		err = rules.Exclude(pattern)
+		if err != nil {
+			return rules, command_arguments, err
+		}
+	}
+	for _, pattern := range includes { // Includes override the excludes
+		err = rules.Include(pattern)
+		if err != nil {
+			return rules, command_arguments, err
+		}
+	}
+	return rules, command_arguments, nil
+}
+
+// Walks the directory like filepath.WalkDir, without the paths the rules exclude.
+// The .cdignore file of every walked directory adds its patterns for the paths under it.
+// The walk function gets paths relative to the directory.
+func walkIncluded(dirPath string, rules Helper.IgnoreRules, walk func(contentPath string, relativePath string, contentInfo fs.DirEntry) error) error {
+	rules = rules.Clone() // The ignore files of this walk don't change the given rules
+	return walkRules(dirPath, &rules, walk)
+}
+
+// Walks the directory like walkIncluded, the patterns of the walked ignore files are added to the rules
+func walkRules(dirPath string, rules *Helper.IgnoreRules, walk func(contentPath string, relativePath string, contentInfo fs.DirEntry) error) error {
+	return filepath.WalkDir(dirPath, func(contentPath string, contentInfo fs.DirEntry, err error) error {
+		if err != nil {
+			return &ClientErrors.ReadFileInfoError{Filename: contentPath, Err: err}
+		}
+		relativePath, err := filepath.Rel(dirPath, contentPath)
+		if err != nil {
+			return &ClientErrors.ConvertToRelative{}
+		}
+		slashPath := filepath.ToSlash(relativePath)
+
+		if relativePath != "." && rules.Excluded(slashPath, contentInfo.IsDir()) {
+			if contentInfo.IsDir() { // Nothing under an excluded directory is walked
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if contentInfo.IsDir() {
+			ignoreFile := filepath.Join(contentPath, ignoreFileName)
+			if exists, _ := Helper.IsPathExists(ignoreFile); exists {
+				err = rules.ReadFile(ignoreFile, slashPath)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return walk(contentPath, relativePath, contentInfo)
+	})
+}
+
+// Returns whether the rules exclude the path or one of the directories above it
+func excludedPath(rules Helper.IgnoreRules, slashPath string, isDir bool) bool {
+	parts := strings.Split(slashPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if rules.Excluded(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return rules.Excluded(slashPath, isDir)
+}
@@ -0,0 +1,131 @@
+package FileRequestsManager
+
+import (
+	"client/Helper"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Creates the files in a new directory, paths use "/" separators
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		os.MkdirAll(filepath.Dir(fullPath), 0o755)
+		err := os.WriteFile(fullPath, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Returns the files the walk visits, with "/" separators
+func includedFiles(t *testing.T, dir string, rules Helper.IgnoreRules) []string {
+	t.Helper()
+	var files []string
+	err := walkIncluded(dir, rules, func(_ string, relativePath string, contentInfo fs.DirEntry) error {
+		if !contentInfo.IsDir() {
+			files = append(files, filepath.ToSlash(relativePath))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// The ignore files of a project and the files its upload sends
+var ignoredProject = map[string]string{
+	".cdignore":           "*.log\nbuild/\n/notes-*.txt\n",
+	"main.go":             "package main",
+	"app.log":             "log",
+	"notes-1.txt":         "notes",
+	"build/out.bin":       "binary",
+	"src/notes-2.txt":     "nested notes",
+	"src/build":           "a file named like the ignored folder",
+	"src/.cdignore":       "!keep.log\n[0-9].tmp\n",
+	"src/keep.log":        "kept log",
+	"src/other.log":       "log",
+	"src/1.tmp":           "tmp",
+	"src/a.tmp":           "tmp",
+	"src/deep/keep.log":   "deep kept log",
+	"src/deep/cache/x.go": "package cache",
+}
+
+func TestWalkIncluded(t *testing.T) {
+	dir := writeTree(t, ignoredProject)
+	var rules Helper.IgnoreRules
+	rules.Exclude("cache/")
+
+	want := []string{".cdignore", "main.go", "src/.cdignore", "src/a.tmp", "src/build", "src/deep/keep.log", "src/keep.log", "src/notes-2.txt"}
+	if files := includedFiles(t, dir, rules); !slices.Equal(files, want) {
+		t.Fatalf("walked %q, want %q", files, want)
+	}
+	// The ignore files of the walk haven't been added to the given rules
+	if rules.Excluded("app.log", false) {
+		t.Fatal("the walk has changed the given rules")
+	}
+}
+
+func TestExcludedPath(t *testing.T) {
+	var rules Helper.IgnoreRules
+	rules.Exclude("build/")
+	rules.Include("build/keep.txt") // A file under an excluded directory is never walked
+	rules.Exclude("*.log")
+	tests := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{path: "build", isDir: true, excluded: true},
+		{path: "build/out.bin", excluded: true},
+		{path: "build/keep.txt", excluded: true},
+		{path: "src/build/out.bin", excluded: true},
+		{path: "src/app.log", excluded: true},
+		{path: "src/build"},
+		{path: "src/main.go"},
+	}
+	for _, test := range tests {
+		if excluded := excludedPath(rules, test.path, test.isDir); excluded != test.excluded {
+			t.Errorf("%q: excluded %v, want %v", test.path, excluded, test.excluded)
+		}
+	}
+}
+
+func TestIgnoredDirectorySize(t *testing.T) {
+	dir := writeTree(t, ignoredProject)
+	server, socket := startSession(t)
+	var rules Helper.IgnoreRules
+	rules.Exclude("cache/")
+	size, err := getDirSize(dir, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := JobsCreated()
+	_, err = HandleUploadDirectory([]string{"--exclude", "cache/", dir}, socket)
+	if err == nil {
+		err = WaitForJobs(created)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	included := includedFiles(t, dir, rules)
+	var sent uint64
+	for path := range ignoredProject {
+		data, err := server.ReadFile("bob", filepath.Base(dir)+"/"+path)
+		if (err == nil) != slices.Contains(included, path) {
+			t.Errorf("%s: uploaded %v, included %v", path, err == nil, slices.Contains(included, path))
+		}
+		sent += uint64(len(data))
+	}
+	if sent != size {
+		t.Fatalf("the upload has sent %d bytes of files, the directory's size is %d", sent, size)
+	}
+}
@@ -0,0 +1,419 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Every upload and download runs as a job on its own goroutine. A job has an ID, a state and counts the bytes it has transferred.
+// Jobs are stopped through their context, which closes their transmission sockets.
+// File jobs share their ID with their saved transfer, so a paused job is continued with resume <id>.
+
+type jobState int
+
+const (
+	jobRunning jobState = iota
+	jobPaused
+	jobFinished
+	jobFailed
+	jobCancelled
+)
+
+type jobKind string
+
+const (
+	uploadJob         jobKind = "upload"
+	downloadJob       jobKind = "download"
+	uploadDirJob      jobKind = "uploaddir"
+	downloadDirJob    jobKind = "downloaddir"
+	syncJob           jobKind = "sync"
+	watchJob          jobKind = "watch"
+	jobStateRunning           = "running"
+	jobStatePaused            = "paused"
+	jobStateFinished          = "finished"
+	jobStateFailed            = "failed"
+	jobStateCancelled         = "cancelled"
+)
+
+var progressSaveInterval = 5 * time.Second // How often running file jobs save their offset, so a killed client can still resume them
+
+var (
+	errJobCancelled = errors.New("cancelled by the user")
+	errJobPaused    = errors.New("paused by the user")
+)
+
+func (state jobState) String() string {
+	switch state {
+	case jobRunning:
+		return jobStateRunning
+	case jobPaused:
+		return jobStatePaused
+	case jobFinished:
+		return jobStateFinished
+	case jobFailed:
+		return jobStateFailed
+	default:
+		return jobStateCancelled
+	}
+}
+
+// An upload or a download running in the background
+type transferJob struct {
+	ID          int
+	order       int // How many jobs have been created in this session before it and including it
+	kind        jobKind
+	source      string
+	destination string
+	size        int64             // Bytes to transfer, 0 if unknown
+	record      *resumeRecord     // Saved transfer of a file job, nil for directories
+	limiter     *rateLimiter      // Limits the job on top of the global limit
+	conflicts   *conflictResolver // Skipped and renamed files listed when the job stops, nil if the job doesn't resolve conflicts
+
+	done     atomic.Int64 // Bytes transferred
+	sample   rateSample   // Only used by the progress reporter
+	current  fileProgress // File a directory job is transferring
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	finished chan struct{} // Closed once the job has stopped and its result has been saved
+
+	mu    sync.Mutex
+	state jobState
+	err   error
+}
+
+var (
+	jobsLock    sync.Mutex
+	jobs        = make(map[int]*transferJob) // Jobs of this session by ID
+	jobsCreated int
+)
+
+// Creates a job limited to rate and adds it to the jobs list. File jobs take the ID of their saved transfer
+func newJob(kind jobKind, source string, destination string, size int64, record *resumeRecord, rate int64) (*transferJob, error) {
+	id := 0
+	if record != nil {
+		id = record.ID
+	} else {
+		var err error
+		id, err = nextJobID()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	transfer := &transferJob{ID: id, kind: kind, source: source, destination: destination, size: size, record: record, limiter: &rateLimiter{rate: rate}, ctx: ctx, cancel: cancel, finished: make(chan struct{})}
+	if record != nil {
+		transfer.done.Store(record.Offset)
+	}
+	transfer.sample = rateSample{done: transfer.done.Load(), at: time.Now()}
+	jobsLock.Lock()
+	jobsCreated++
+	transfer.order = jobsCreated
+	jobs[id] = transfer
+	jobsLock.Unlock()
+	return transfer, nil
+}
+
+// Returns the job with the given ID
+func findJob(id string) (*transferJob, error) {
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, &ClientErrors.JobNotFoundError{ID: id}
+	}
+	jobsLock.Lock()
+	defer jobsLock.Unlock()
+	transfer, ok := jobs[number]
+	if !ok {
+		return nil, &ClientErrors.JobNotFoundError{ID: id}
+	}
+	return transfer, nil
+}
+
+// Returns whether a job with the given ID is running
+func isJobRunning(id int) bool {
+	jobsLock.Lock()
+	transfer, ok := jobs[id]
+	jobsLock.Unlock()
+	return ok && transfer.State() == jobRunning
+}
+
+func (transfer *transferJob) State() jobState {
+	transfer.mu.Lock()
+	defer transfer.mu.Unlock()
+	return transfer.state
+}
+
+// Limits and counts the job's bytes on the socket and closes the socket when the job is stopped.
+// The returned function stops watching the job.
+func (transfer *transferJob) attach(socket *net.Conn) func() bool {
+	return transfer.attachDirection(socket, transfer.kind != downloadJob && transfer.kind != downloadDirJob)
+}
+
+// Same as attach, for jobs that transfer in both directions. The bytes are counted in the given direction
+func (transfer *transferJob) attachDirection(socket *net.Conn, upload bool) func() bool {
+	throttled := &throttledConn{Conn: *socket, ctx: transfer.ctx, limiters: []*rateLimiter{bandwidth, transfer.limiter}}
+	*socket = &countingConn{Conn: throttled, upload: upload, done: &transfer.done}
+	conn := *socket
+	return context.AfterFunc(transfer.ctx, func() {
+		conn.Close()
+	})
+}
+
+// Runs the transfer on its own goroutine. The transfer returns the offset its file has been transferred up to
+func (transfer *transferJob) run(work func() (int64, error)) {
+	go func() {
+		offset, err := work()
+		transfer.finish(offset, err)
+	}()
+	startReporting()
+}
+
+// Saves the offset of the job's transfer periodically until the returned function is called.
+// Only for jobs that transfer their file in order, so every byte before the offset has been transferred.
+func (transfer *transferJob) saveProgress() func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				record := *transfer.record
+				record.Offset = transfer.done.Load()
+				saveTransfer(record) // The offset is saved again when the job stops
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped // Don't save after the job has stopped and its transfer may be removed
+	}
+}
+
+// Saves the job's result and its saved transfer according to how it has stopped
+func (transfer *transferJob) finish(offset int64, err error) {
+	if transfer.record != nil { // The job's limit may have been changed while it was running
+		transfer.record.Limit = transfer.limiter.limit()
+	}
+	state := jobFinished
+	switch context.Cause(transfer.ctx) {
+	case errJobPaused:
+		state = jobPaused
+		if transfer.record != nil {
+			record := *transfer.record
+			record.Offset = offset
+			_, err = saveTransfer(record)
+		}
+		if err == nil {
+			printLine("Job %d has been paused at %d of %d bytes, type \"resume %d\" to continue it\n", transfer.ID, offset, transfer.size, transfer.ID)
+		}
+	case errJobCancelled:
+		state = jobCancelled
+		err = nil
+		if transfer.record != nil {
+			err = discardTransfer(*transfer.record)
+		}
+		printLine("Job %d has been cancelled\n", transfer.ID)
+	default:
+		if err != nil {
+			state = jobFailed
+		}
+		if transfer.record != nil {
+			finishTransfer(*transfer.record, offset, err)
+		} else if err != nil {
+			printLine("%s\n", err.Error())
+		}
+	}
+	if err != nil && state != jobFailed {
+		printLine("%s\n", err.Error())
+	}
+	transfer.conflicts.printSummary()
+
+	transfer.mu.Lock()
+	transfer.state = state
+	transfer.err = err
+	transfer.mu.Unlock()
+	transfer.cancel(nil) // Release the context's resources
+	close(transfer.finished)
+}
+
+// Returns how many jobs have been created in this session, to wait only for the jobs created after it
+func JobsCreated() int {
+	jobsLock.Lock()
+	defer jobsLock.Unlock()
+	return jobsCreated
+}
+
+// Waits until every job created after the first given amount of jobs has stopped, including the jobs created while waiting.
+// Watch jobs only stop when they are cancelled, they aren't waited for.
+// Returns an error if any of them has failed, their errors have been printed already.
+func WaitForJobs(created int) error {
+	waited := make(map[*transferJob]bool) // A resumed job replaces the paused job of the same ID
+	failed := 0
+	for {
+		var pending []*transferJob
+		for _, transfer := range sortedJobs() {
+			if transfer.order > created && transfer.kind != watchJob && !waited[transfer] {
+				pending = append(pending, transfer)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		for _, transfer := range pending {
+			<-transfer.finished
+			waited[transfer] = true
+			if transfer.State() == jobFailed {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return &ClientErrors.JobsFailedError{Count: failed}
+	}
+	return nil
+}
+
+// Removes a saved transfer that won't be continued, with the partial file of a download
+func discardTransfer(record resumeRecord) error {
+	if !record.Upload {
+		err := os.Remove(partialPath(record.LocalPath))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return removeTransfer(record.ID)
+}
+
+// Socket that counts the bytes of a job, sent bytes for uploads and received bytes for downloads
+type countingConn struct {
+	net.Conn
+	upload bool
+	done   *atomic.Int64
+}
+
+func (conn *countingConn) Read(buffer []byte) (int, error) {
+	read, err := conn.Conn.Read(buffer)
+	if !conn.upload {
+		conn.done.Add(int64(read))
+	}
+	return read, err
+}
+
+func (conn *countingConn) Write(buffer []byte) (int, error) {
+	written, err := conn.Conn.Write(buffer)
+	if conn.upload {
+		conn.done.Add(int64(written))
+	}
+	return written, err
+}
+
+// Returns the jobs of this session by ID
+func sortedJobs() []*transferJob {
+	jobsLock.Lock()
+	list := make([]*transferJob, 0, len(jobs))
+	for _, transfer := range jobs {
+		list = append(list, transfer)
+	}
+	jobsLock.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Handles jobs command, lists the jobs of this session
+func HandleJobs() string {
+	list := sortedJobs()
+	if len(list) == 0 {
+		return "There are no jobs\n"
+	}
+
+	var builder strings.Builder
+	for _, transfer := range list {
+		transfer.mu.Lock()
+		state, err := transfer.state, transfer.err
+		transfer.mu.Unlock()
+
+		done := transfer.done.Load()
+		progress := fmt.Sprintf("%d bytes", done)
+		if transfer.size > 0 {
+			progress = fmt.Sprintf("%d/%d bytes", min(done, transfer.size), transfer.size)
+		}
+		fmt.Fprintf(&builder, "%d\t%s\t%s\t%s\t%s -> %s", transfer.ID, transfer.kind, state, progress, transfer.source, transfer.destination)
+		if err != nil {
+			fmt.Fprintf(&builder, "\t%s", strings.ReplaceAll(err.Error(), "\n", " "))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// Handles cancel command, stops the job and drops its saved transfer
+func HandleCancel(command_arguments []string) (string, error) {
+	if len(command_arguments) != 1 {
+		return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: 1}
+	}
+	transfer, err := findJob(command_arguments[0])
+	if err != nil { // If it's not a job of this session, it may be an interrupted transfer
+		id, convertErr := strconv.Atoi(command_arguments[0])
+		if convertErr != nil {
+			return "", err
+		}
+		record, findErr := findTransfer(id)
+		if findErr != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Transfer %d has been cancelled\n", id), discardTransfer(record)
+	}
+
+	switch transfer.State() {
+	case jobRunning:
+		transfer.cancel(errJobCancelled)
+		return "", nil
+	case jobPaused: // A paused job only has its saved transfer left
+		err = discardTransfer(*transfer.record)
+		if err != nil {
+			return "", err
+		}
+		transfer.mu.Lock()
+		transfer.state = jobCancelled
+		transfer.mu.Unlock()
+		return fmt.Sprintf("Job %d has been cancelled\n", transfer.ID), nil
+	}
+	if record, findErr := findTransfer(transfer.ID); findErr == nil { // A job that has failed may have saved its transfer
+		return fmt.Sprintf("Transfer %d has been cancelled\n", transfer.ID), discardTransfer(record)
+	}
+	return "", &ClientErrors.JobStateError{ID: transfer.ID, State: transfer.State().String(), Action: "cancelled"}
+}
+
+// Handles pause command, stops the job and saves its offset so it can be resumed
+func HandlePause(command_arguments []string) error {
+	if len(command_arguments) != 1 {
+		return &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: 1}
+	}
+	transfer, err := findJob(command_arguments[0])
+	if err != nil {
+		return err
+	}
+	if transfer.record == nil { // Only single files are saved for resuming
+		return &ClientErrors.JobStateError{ID: transfer.ID, State: fmt.Sprintf("a %s job", transfer.kind), Action: "paused"}
+	}
+	if transfer.State() != jobRunning {
+		return &ClientErrors.JobStateError{ID: transfer.ID, State: transfer.State().String(), Action: "paused"}
+	}
+	transfer.cancel(errJobPaused)
+	return nil
+}
@@ -0,0 +1,140 @@
+package FileRequestsManager
+
+import (
+	"bytes"
+	"client/TestServer"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunningTransferSavesOffset(t *testing.T) {
+	server, socket := startSession(t)
+	data := bytes.Repeat([]byte{7}, 256*1024)
+	server.WriteFile("bob", "big.bin", data)
+	server.SetStreamRate(256 * 1024) // About a second for the whole file
+	saved := progressSaveInterval
+	progressSaveInterval = 20 * time.Millisecond
+	t.Cleanup(func() { progressSaveInterval = saved })
+
+	created := JobsCreated()
+	err := HandleDownloadFile([]string{"big.bin", t.TempDir()}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The offset must reach the saved transfer while the download is still running, a killed client resumes from it
+	var offset int64
+	for wait := 0; wait < 100 && (offset == 0 || offset == int64(len(data))); wait++ {
+		time.Sleep(10 * time.Millisecond)
+		resumeLock.Lock()
+		store, err := loadResumeStore()
+		resumeLock.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range store.Transfers {
+			offset = record.Offset
+		}
+	}
+	if offset == 0 || offset == int64(len(data)) {
+		t.Fatalf("saved offset is %d while downloading %d bytes", offset, len(data))
+	}
+	err = WaitForJobs(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Starts downloading a file that takes about a second and returns its job once it has received some of the file
+func startSlowDownload(t *testing.T) (*TestServer.Server, *net.Conn, *transferJob, []byte, string) {
+	t.Helper()
+	server, socket := startSession(t)
+	data := bytes.Repeat([]byte{7}, 256*1024)
+	server.WriteFile("bob", "big.bin", data)
+	server.SetStreamRate(256 * 1024)
+
+	dir := t.TempDir()
+	created := JobsCreated()
+	err := HandleDownloadFile([]string{"big.bin", dir}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := createdJob(t, created, downloadJob)
+	for wait := 0; wait < 100 && transfer.done.Load() == 0; wait++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if transfer.State() != jobRunning || transfer.done.Load() == 0 {
+		t.Fatalf("job is %s after %d bytes", transfer.State(), transfer.done.Load())
+	}
+	return server, socket, transfer, data, dir
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	_, _, transfer, data, dir := startSlowDownload(t)
+	id := strconv.Itoa(transfer.ID)
+
+	_, err := HandleCancel([]string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-transfer.finished:
+	case <-time.After(500 * time.Millisecond): // Well before the download would have finished
+		t.Fatal("the cancelled job is still running")
+	}
+	if transfer.State() != jobCancelled || transfer.done.Load() == int64(len(data)) {
+		t.Fatalf("job is %s after %d bytes", transfer.State(), transfer.done.Load())
+	}
+	if !strings.Contains(HandleJobs(), jobCancelled.String()) {
+		t.Fatalf("jobs list doesn't report the cancelled job:\n%s", HandleJobs())
+	}
+	if _, err := findTransfer(transfer.ID); err == nil {
+		t.Fatal("the cancelled transfer is still saved")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("the cancelled download has left %d files", len(entries))
+	}
+}
+
+func TestPauseAndResumeJob(t *testing.T) {
+	server, socket, transfer, data, dir := startSlowDownload(t)
+	id := strconv.Itoa(transfer.ID)
+
+	err := HandlePause([]string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-transfer.finished
+	if transfer.State() != jobPaused {
+		t.Fatalf("job is %s", transfer.State())
+	}
+	record, err := findTransfer(transfer.ID)
+	if err != nil || record.Offset == 0 || record.Offset == int64(len(data)) {
+		t.Fatalf("paused at %d of %d bytes: %v", record.Offset, len(data), err)
+	}
+
+	server.SetStreamRate(0)
+	created := JobsCreated()
+	_, err = HandleResume([]string{id}, socket)
+	if err == nil {
+		err = WaitForJobs(created)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := createdJob(t, created, downloadJob)
+	if resumed.State() != jobFinished {
+		t.Fatalf("resumed job is %s", resumed.State())
+	}
+	downloaded, err := os.ReadFile(filepath.Join(dir, "big.bin"))
+	if err != nil || !bytes.Equal(downloaded, data) {
+		t.Fatalf("downloaded %d bytes of %d: %v", len(downloaded), len(data), err)
+	}
+	if _, err := findTransfer(transfer.ID); err == nil {
+		t.Fatal("the finished transfer is still saved")
+	}
+}
@@ -0,0 +1,55 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Requests"
+	"io/fs"
+	"os"
+	"time"
+)
+
+const noMetadataOption = "no-metadata" // Modification times and permissions aren't sent with uploads or restored by downloads
+
+// A folder created by a directory download, its metadata is restored after the files in it have been written
+type restoredFolder struct {
+	path     string
+	metadata Requests.Metadata
+}
+
+// Returns the modification time and permission bits of a local file or folder
+func readMetadata(fileInfo fs.FileInfo) Requests.Metadata {
+	return Requests.Metadata{ModTime: fileInfo.ModTime().UnixNano(), Mode: uint32(fileInfo.Mode().Perm())}
+}
+
+// Sets the modification time and permission bits of a local file or folder, the ones that haven't been sent are left as they are
+func restoreMetadata(path string, metadata Requests.Metadata) error {
+	if metadata.Mode != 0 {
+		err := os.Chmod(path, fs.FileMode(metadata.Mode).Perm())
+		if err != nil {
+			return &ClientErrors.MetadataError{Path: path, Err: err}
+		}
+	}
+	if metadata.ModTime != 0 {
+		modTime := time.Unix(0, metadata.ModTime)
+		err := os.Chtimes(path, modTime, modTime)
+		if err != nil {
+			return &ClientErrors.MetadataError{Path: path, Err: err}
+		}
+	}
+	return nil
+}
+
+// Restores the metadata of a downloaded file or folder. A failure is only printed, the content itself is complete
+func tryRestoreMetadata(path string, metadata Requests.Metadata) {
+	err := restoreMetadata(path, metadata)
+	if err != nil {
+		printLine("%s\n", err.Error())
+	}
+}
+
+// Restores the metadata of the downloaded folders, the deepest first since writing in a folder changes its modification time
+func restoreFolders(folders []restoredFolder) {
+	for i := len(folders) - 1; i >= 0; i-- {
+		tryRestoreMetadata(folders[i].path, folders[i].metadata)
+	}
+}
@@ -0,0 +1,50 @@
+package FileRequestsManager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadDirectoryMetadata(t *testing.T) {
+	_, socket := startSession(t)
+	album := filepath.Join(t.TempDir(), "Album")
+	os.MkdirAll(filepath.Join(album, "2019"), 0o755)
+	os.WriteFile(filepath.Join(album, "2019", "beach.jpg"), []byte("beach"), 0o600)
+	old := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+	for _, path := range []string{filepath.Join(album, "2019", "beach.jpg"), filepath.Join(album, "2019"), album} {
+		os.Chtimes(path, old, old)
+	}
+	os.Chmod(album, 0o750)
+
+	created := JobsCreated()
+	_, err := HandleUploadDirectory([]string{album}, socket)
+	if err == nil {
+		err = WaitForJobs(created)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloads := t.TempDir()
+	_, err = HandleDownloadDir([]string{"Album", downloads}, socket)
+	if err == nil {
+		err = WaitForJobs(created)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"Album", "Album/2019", "Album/2019/beach.jpg"} {
+		info, err := os.Stat(filepath.Join(downloads, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(old) {
+			t.Errorf("%s has been modified at %s, want %s", path, info.ModTime(), old)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(downloads, "Album")); err == nil && info.Mode().Perm() != 0o750 {
+		t.Errorf("the downloaded folder's permissions are %s", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,177 @@
+package FileRequestsManager
+
+import (
+	"client/Helper"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The running jobs report their progress on a status line when stdout is a terminal, otherwise every running job
+// prints a plain progress line once in a while. The status line is the line above the cursor, which is the empty line
+// under the last output while the prompt waits, so it's redrawn without touching what the user is typing.
+// Messages of the jobs are printed with printLine, which leaves an empty line for the status under them.
+
+const (
+	terminalInterval = 500 * time.Millisecond // How often the status line is redrawn
+	plainInterval    = 5 * time.Second        // How often the plain progress lines are printed
+	rateSmoothing    = 0.3                    // Weight of the latest rate sample against the earlier ones
+	statusWidth      = 79                     // Longest status line, a wrapped line can't be cleared
+	drawStatus       = "\0337\033[F\033[K"    // Saves the cursor, then moves to the start of the line above it and clears it
+	restoreCursor    = "\0338"
+)
+
+var (
+	outputLock  sync.Mutex
+	statusShown bool // Whether the status line is on the screen, above the cursor
+	reporting   bool // Whether the reporter goroutine is running
+	isTerminal  = Helper.IsTerminal(os.Stdout)
+)
+
+// The file a directory job is transferring
+type fileProgress struct {
+	name  string
+	size  int64
+	start int64 // The job's bytes when the file has started
+}
+
+// Measures a job's rate from its bytes every time its progress is reported
+type rateSample struct {
+	done int64
+	at   time.Time
+	rate float64 // Bytes per second
+}
+
+// Returns the job's rate, smoothed so a single slow or fast sample doesn't jump the ETA
+func (sample *rateSample) update(done int64, now time.Time) float64 {
+	if elapsed := now.Sub(sample.at).Seconds(); elapsed > 0 {
+		current := float64(done-sample.done) / elapsed
+		if sample.rate == 0 {
+			sample.rate = current
+		} else {
+			sample.rate = rateSmoothing*current + (1-rateSmoothing)*sample.rate
+		}
+	}
+	sample.done, sample.at = done, now
+	return sample.rate
+}
+
+// Prints a message of a job, the status line is redrawn under it
+func printLine(format string, args ...any) {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	fmt.Printf(format, args...)
+	if statusShown {
+		fmt.Println()
+	}
+}
+
+// Prints a line of the command line, such as a command's output, the same way as the messages of the jobs
+func PrintLine(format string, args ...any) {
+	printLine(format, args...)
+}
+
+// Prints the error that has stopped a one-shot command, a script or piped commands to the standard error
+func PrintError(err error) {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	fmt.Fprintln(os.Stderr, err.Error())
+}
+
+// Prints the prompt after the current path, the status line stays above the line the user types on
+func PrintPrompt(prompt string) {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	fmt.Print(CurrentPath + prompt)
+}
+
+// Sets the file a directory job is transferring
+func (transfer *transferJob) startFile(name string, size int64) {
+	transfer.mu.Lock()
+	defer transfer.mu.Unlock()
+	transfer.current = fileProgress{name: name, size: size, start: transfer.done.Load()}
+}
+
+// Returns whether the job is waiting for something to transfer, a watch job only transfers after its folder has changed
+func (transfer *transferJob) idle() bool {
+	transfer.mu.Lock()
+	defer transfer.mu.Unlock()
+	return transfer.kind == watchJob && transfer.current.name == ""
+}
+
+// Renders the job's progress, and the progress of its current file for directories
+func (transfer *transferJob) progressLine(now time.Time) string {
+	done := transfer.done.Load()
+	rate := transfer.sample.update(done, now)
+	line := fmt.Sprintf("Job %d %s %s", transfer.ID, filepath.Base(transfer.source), Helper.FormatProgress(done, transfer.size, rate))
+
+	transfer.mu.Lock()
+	current := transfer.current
+	transfer.mu.Unlock()
+	if current.name != "" {
+		line += fmt.Sprintf(" [%s %s]", current.name, Helper.FormatProgress(done-current.start, current.size, 0))
+	}
+	return line
+}
+
+// Shortens the status line to the status width, names may have characters of several bytes
+func fitStatus(status string) string {
+	runes := []rune(status)
+	if len(runes) <= statusWidth {
+		return status
+	}
+	return string(runes[:statusWidth-3]) + "..."
+}
+
+// Starts reporting the progress of the running jobs, unless it's reported already
+func startReporting() {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	if reporting {
+		return
+	}
+	reporting = true
+	go reportProgress()
+}
+
+// Reports the progress of the running jobs until none is left
+func reportProgress() {
+	interval := plainInterval
+	if isTerminal {
+		interval = terminalInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		outputLock.Lock() // A job that starts while the last one stops is seen here, or starts its own reporter
+		var lines []string
+		for _, transfer := range sortedJobs() {
+			if transfer.State() == jobRunning && !transfer.idle() {
+				lines = append(lines, transfer.progressLine(now))
+			}
+		}
+
+		switch {
+		case len(lines) == 0: // Every job has stopped
+			if statusShown {
+				fmt.Print(drawStatus + restoreCursor)
+				statusShown = false
+			}
+			reporting = false
+			outputLock.Unlock()
+			return
+		case isTerminal:
+			fmt.Print(drawStatus + fitStatus(strings.Join(lines, " | ")) + restoreCursor)
+			statusShown = true
+		default:
+			for _, line := range lines {
+				fmt.Println(line)
+			}
+		}
+		outputLock.Unlock()
+	}
+}
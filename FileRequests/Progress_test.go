@@ -0,0 +1,56 @@
+package FileRequestsManager
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFitStatus(t *testing.T) {
+	tests := []string{
+		"Job 1 notes.txt 50%",
+		strings.Repeat("a", statusWidth),
+		strings.Repeat("a", statusWidth+1),
+		strings.Repeat("ש", statusWidth),
+		strings.Repeat("ab", statusWidth/2) + strings.Repeat("日本", statusWidth),
+	}
+	for _, status := range tests {
+		fitted := fitStatus(status)
+		if !utf8.ValidString(fitted) {
+			t.Errorf("%q: cut a character in half: %q", status, fitted)
+		}
+		if count := utf8.RuneCountInString(fitted); count > statusWidth {
+			t.Errorf("%q: %d characters wide", status, count)
+		}
+		if utf8.RuneCountInString(status) <= statusWidth && fitted != status {
+			t.Errorf("%q: shortened to %q", status, fitted)
+		}
+	}
+}
+
+func TestDownloadDirectoryTotal(t *testing.T) {
+	server, socket := startSession(t)
+	server.MakeDir("bob", "Photos")
+	server.MakeDir("bob", "Photos/2024")
+	server.WriteFile("bob", "Photos/a.jpg", make([]byte, 1000))
+	server.WriteFile("bob", "Photos/2024/b.jpg", make([]byte, 2500))
+
+	created := JobsCreated()
+	_, err := HandleDownloadDir([]string{"Photos", t.TempDir()}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, transfer := range sortedJobs() {
+		if transfer.order > created {
+			size = transfer.size
+		}
+	}
+	err = WaitForJobs(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 3500 {
+		t.Fatalf("directory job's total is %d bytes, the files hold 3500", size)
+	}
+}
@@ -0,0 +1,277 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Interrupted transfers are saved to the resume file, so they can be continued with the resume command
+// even after the client has been restarted. A transfer is saved when it starts and removed when it's finished.
+
+var (
+	resumeFile  string      // Path of the file interrupted transfers are saved to, kept in memory when empty
+	resumeLock  sync.Mutex  // Transfers finish on their own goroutines
+	memoryStore resumeStore // Transfers of this session when there is no resume file
+)
+
+// A file transfer that can be continued from its offset
+type resumeRecord struct {
+	ID         int    `json:"id"`
+	Upload     bool   `json:"upload"`
+	LocalPath  string `json:"local_path"`            // File on this machine
+	CloudPath  string `json:"cloud_path"`            // Absolute cloud folder of an upload, absolute cloud file of a download
+	Size       int64  `json:"size"`                  // File's size in bytes
+	Offset     int64  `json:"offset"`                // Bytes that have been transferred
+	Limit      int64  `json:"limit"`                 // Rate the transfer is limited to, 0 if it isn't
+	NoMetadata bool   `json:"no_metadata,omitempty"` // The file's modification time and permissions aren't transferred
+}
+
+type resumeStore struct {
+	NextID    int            `json:"next_id"`
+	Transfers []resumeRecord `json:"transfers"`
+}
+
+// Sets the file interrupted transfers are saved to
+func SetResumeFile(path string) {
+	resumeFile = path
+}
+
+// Loads the saved transfers. Must be called with the lock held
+func loadResumeStore() (resumeStore, error) {
+	store := resumeStore{NextID: 1}
+	if resumeFile == "" {
+		store.NextID = max(store.NextID, memoryStore.NextID)
+		store.Transfers = append(store.Transfers, memoryStore.Transfers...)
+		return store, nil
+	}
+	data, err := os.ReadFile(resumeFile)
+	if errors.Is(err, os.ErrNotExist) { // If no transfer has been saved yet
+		return store, nil
+	}
+	if err != nil {
+		return store, &ClientErrors.ResumeFileError{Path: resumeFile, Err: err}
+	}
+	err = json.Unmarshal(data, &store)
+	if err != nil {
+		return store, &ClientErrors.ResumeFileError{Path: resumeFile, Err: err}
+	}
+	return store, nil
+}
+
+// Saves the transfers. Must be called with the lock held
+func (store resumeStore) save() error {
+	if resumeFile == "" {
+		memoryStore = store
+		return nil
+	}
+	err := writeJSONFile(resumeFile, store)
+	if err != nil {
+		return &ClientErrors.ResumeFileError{Path: resumeFile, Err: err}
+	}
+	return nil
+}
+
+// Writes the value to the file as JSON, the file is replaced at once so a crash can't leave half of it
+func writeJSONFile(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	err = os.WriteFile(temp, data, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// Saves the transfer, a new transfer gets its ID. Returns the saved transfer
+func saveTransfer(record resumeRecord) (resumeRecord, error) {
+	resumeLock.Lock()
+	defer resumeLock.Unlock()
+	store, err := loadResumeStore()
+	if err != nil {
+		return record, err
+	}
+
+	if record.ID == 0 { // If the transfer hasn't been saved before
+		record.ID = store.NextID
+		store.NextID++
+		store.Transfers = append(store.Transfers, record)
+		return record, store.save()
+	}
+	for i := range store.Transfers {
+		if store.Transfers[i].ID == record.ID {
+			store.Transfers[i] = record
+			return record, store.save()
+		}
+	}
+	store.Transfers = append(store.Transfers, record)
+	return record, store.save()
+}
+
+// Returns a new job ID, jobs and saved transfers share the same IDs
+func nextJobID() (int, error) {
+	resumeLock.Lock()
+	defer resumeLock.Unlock()
+	store, err := loadResumeStore()
+	if err != nil {
+		return 0, err
+	}
+	id := store.NextID
+	store.NextID++
+	return id, store.save()
+}
+
+// Removes a finished transfer
+func removeTransfer(id int) error {
+	resumeLock.Lock()
+	defer resumeLock.Unlock()
+	store, err := loadResumeStore()
+	if err != nil {
+		return err
+	}
+	for i := range store.Transfers {
+		if store.Transfers[i].ID == id {
+			store.Transfers = append(store.Transfers[:i], store.Transfers[i+1:]...)
+			return store.save()
+		}
+	}
+	return nil
+}
+
+// Returns the saved transfer with the given ID
+func findTransfer(id int) (resumeRecord, error) {
+	resumeLock.Lock()
+	defer resumeLock.Unlock()
+	store, err := loadResumeStore()
+	if err != nil {
+		return resumeRecord{}, err
+	}
+	for _, record := range store.Transfers {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+	return resumeRecord{}, &ClientErrors.TransferNotFoundError{ID: strconv.Itoa(id)}
+}
+
+// Saves the offset of a transfer that has stopped, or removes it if it has finished or can't be continued
+func finishTransfer(record resumeRecord, offset int64, err error) {
+	var mismatch *ClientErrors.ChecksumMismatchError
+	if err == nil || errors.As(err, &mismatch) { // A corrupted file has to be transferred from the start
+		if err != nil {
+			printLine("%s\n", err.Error())
+		}
+		removeErr := discardTransfer(record) // The partial file of a finished download has been moved already
+		if removeErr != nil {
+			printLine("%s\n", removeErr.Error())
+		}
+		return
+	}
+
+	printLine("%s\n", err.Error())
+	record.Offset = offset
+	_, err = saveTransfer(record)
+	if err != nil {
+		printLine("%s\n", err.Error())
+		return
+	}
+	printLine("Transfer %d has stopped at %d of %d bytes, type \"resume %d\" to continue it\n", record.ID, record.Offset, record.Size, record.ID)
+}
+
+// Returns the absolute cloud path of a path relative to the current directory
+func absoluteCloudPath(path string) string {
+	if strings.HasPrefix(path, rootPrefix) { // If path is already absolute
+		return path
+	}
+	if path == "" {
+		return CurrentPath
+	}
+	return strings.TrimSuffix(CurrentPath, "\\") + "\\" + path
+}
+
+// Lists the interrupted transfers
+func listTransfers() (string, error) {
+	resumeLock.Lock()
+	defer resumeLock.Unlock()
+	store, err := loadResumeStore()
+	if err != nil {
+		return "", err
+	}
+	if len(store.Transfers) == 0 {
+		return "There are no interrupted transfers\n", nil
+	}
+
+	var builder strings.Builder
+	for _, record := range store.Transfers {
+		direction := "download"
+		source, destination := record.CloudPath, record.LocalPath
+		if record.Upload {
+			direction = "upload"
+			source, destination = record.LocalPath, record.CloudPath
+		}
+		fmt.Fprintf(&builder, "%d\t%s\t%s -> %s\t%d/%d bytes\n", record.ID, direction, source, destination, record.Offset, record.Size)
+	}
+	return builder.String(), nil
+}
+
+// Handles resume command, lists the interrupted transfers or continues the one with the given ID
+func HandleResume(command_arguments []string, socket *net.Conn) (string, error) {
+	if len(command_arguments) == 0 { // If no transfer has been chosen
+		return listTransfers()
+	}
+	rate, limited, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	if len(command_arguments) != 1 {
+		return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: 1}
+	}
+	id, err := strconv.Atoi(command_arguments[0])
+	if err != nil {
+		return "", &ClientErrors.TransferNotFoundError{ID: command_arguments[0]}
+	}
+	if isJobRunning(id) {
+		return "", &ClientErrors.JobStateError{ID: id, State: jobStateRunning, Action: "resumed"}
+	}
+	record, err := findTransfer(id)
+	if err != nil {
+		return "", err
+	}
+	if limited { // The transfer keeps its own limit unless a new one is given
+		record.Limit = rate
+	}
+
+	if record.Upload {
+		fileInfo, err := checkContent(record.LocalPath)
+		if err != nil {
+			return "", err
+		}
+		if fileInfo.Size() != record.Size { // If the file has changed since the upload has started
+			removeTransfer(record.ID)
+			return "", &ClientErrors.FileChangedError{Filename: record.LocalPath}
+		}
+		return "", startUpload(record, record.CloudPath, streams, socket)
+	}
+
+	fileInfo, err := os.Stat(partialPath(record.LocalPath))
+	if err != nil { // If the partial file is gone, download it from the start
+		record.Offset = 0
+	} else {
+		record.Offset = min(record.Offset, fileInfo.Size())
+	}
+	return "", startDownload(record, record.CloudPath, streams, nil, socket)
+}
@@ -0,0 +1,231 @@
+package FileRequestsManager
+
+import (
+	"bufio"
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Large files are split to byte ranges (segments) that are transferred over several transmission sockets at once.
+// Every socket starts with a TransferSegment message and every segment is verified with its own digest.
+
+const (
+	minSegmentSize = 4 * 1024 * 1024 // Smallest range worth its own transmission socket
+	streamsOption  = "streams"       // Command option that overrides the configured amount of streams
+)
+
+var streams = 1 // Transmission sockets a large file is split to
+
+// Sets the amount of transmission sockets a large file is split to
+func SetStreams(count int) {
+	streams = count
+}
+
+// A byte range of a file
+type segment struct {
+	Offset int64
+	Length int64
+}
+
+// Removes the --streams option from the command arguments, returns the configured amount if it hasn't been given
+func takeStreamsOption(command_arguments []string) (int, []string, error) {
+	return Helper.TakeIntOption(command_arguments, streamsOption, streams, 1, Requests.MaxStreams)
+}
+
+// Returns the amount of streams worth using for length bytes, at most count
+func segmentCount(length int64, count int) int {
+	return int(max(1, min(int64(count), length/minSegmentSize)))
+}
+
+// Splits the bytes from offset to size to count ranges of about the same length
+func splitSegments(offset int64, size int64, count int) []segment {
+	length := (size - offset + int64(count) - 1) / int64(count)
+	segments := make([]segment, 0, count)
+	for start := offset; start < size; start += length {
+		segments = append(segments, segment{Offset: start, Length: min(length, size-start)})
+	}
+	return segments
+}
+
+// Returns where the first segment that hasn't finished starts, the whole file up to it has been transferred
+func completedOffset(offset int64, segments []segment, errs []error) int64 {
+	for i, part := range segments {
+		if errs[i] != nil {
+			return part.Offset
+		}
+		offset = part.Offset + part.Length
+	}
+	return offset
+}
+
+// Returns the average speed of a transfer
+func formatThroughput(bytes int64, elapsed time.Duration) string {
+	return Helper.FormatRate(int64(float64(bytes) / max(elapsed.Seconds(), 0.001)))
+}
+
+// Opens a transmission socket for one segment of the transfer, the socket is closed if the job is stopped.
+// The returned function stops watching the job.
+func openSegmentSocket(transfer *transferJob, transferID string, part segment) (*net.Conn, func() bool, error) {
+	socket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := json.Marshal(Requests.TransferSegment{TransferID: transferID, Offset: uint64(part.Offset), Length: uint64(part.Length)})
+	if err != nil {
+		(*socket).Close()
+		return nil, nil, &ClientErrors.JsonEncodeError{Err: err}
+	}
+	_, err = Requests.SendRequestInfo(Requests.BuildRequestInfo(Requests.TransferSegmentRequest, data), false, *socket)
+	if err != nil {
+		(*socket).Close()
+		return nil, nil, err
+	}
+	return socket, transfer.attach(socket), nil
+}
+
+// Uploads the file from the transfer's offset over the streams of the grant.
+// Returns the offset the whole file has been uploaded up to.
+func uploadSegments(transfer *transferJob, record resumeRecord, grant Requests.ChunkGrant) (int64, error) {
+	segments := splitSegments(record.Offset, record.Size, grant.Streams)
+	errs := make([]error, len(segments))
+	var wg sync.WaitGroup
+	for i, part := range segments {
+		wg.Add(1)
+		go func(i int, part segment) {
+			defer wg.Done()
+			errs[i] = uploadSegment(transfer, record.LocalPath, grant, part)
+		}(i, part)
+	}
+	wg.Wait()
+	return completedOffset(record.Offset, segments, errs), errors.Join(errs...)
+}
+
+// Sends one range of the file over its own socket, then verifies its digest with the server
+func uploadSegment(transfer *transferJob, path string, grant Requests.ChunkGrant, part segment) error {
+	if grant.ChunksSize <= 0 { // If the server hasn't granted chunks to send the file with
+		return &ClientErrors.ServerBadChunks{}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return &ClientErrors.FileNotExistError{Filename: path}
+	}
+	defer file.Close()
+
+	socket, stop, err := openSegmentSocket(transfer, grant.TransferID, part)
+	if err != nil {
+		return err
+	}
+	defer (*socket).Close()
+	defer stop()
+
+	reader := io.NewSectionReader(file, part.Offset, part.Length)
+	digest := sha256.New()
+	chunk := make([]byte, grant.ChunksSize)
+	var sent int64
+	for sent < part.Length {
+		bytesRead, err := reader.Read(chunk)
+		if bytesRead == empty {
+			if err != nil && err != io.EOF {
+				return &ClientErrors.ReadFileInfoError{Filename: path, Err: err}
+			}
+			break
+		}
+		_, err = (*socket).Write(chunk[:bytesRead])
+		if err != nil {
+			return &ClientErrors.SendDataError{Err: err}
+		}
+		digest.Write(chunk[:bytesRead])
+		sent += int64(bytesRead)
+	}
+	if sent != part.Length { // If the file has changed since its size was sent to the server
+		return &ClientErrors.ShortTransferError{Filename: path, Expected: part.Offset + part.Length, Received: part.Offset + sent}
+	}
+	return verifyUpload(socket, path, digest.Sum(nil))
+}
+
+// Downloads the file from the transfer's offset over the streams of the grant, every stream writes its own range.
+// Returns the offset the whole file has been downloaded up to. The partial file has its full size before the ranges
+// are written, so it's committed only once every range has been received.
+func downloadSegments(transfer *transferJob, record resumeRecord, grant Requests.ChunkGrant) (int64, error) {
+	file, err := os.OpenFile(partialPath(record.LocalPath), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, &ClientErrors.CreateFileError{Filename: record.LocalPath, Err: err}
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err == nil && info.Size() < record.Offset { // The bytes downloaded before are gone, they would be zeros
+		return 0, &ClientErrors.ShortTransferError{Filename: record.LocalPath, Expected: record.Offset, Received: info.Size()}
+	}
+	if err == nil {
+		err = file.Truncate(record.Offset) // Drop anything after the bytes that have been downloaded before
+	}
+	if err == nil {
+		err = file.Truncate(record.Size) // Every range is written in its place
+	}
+	if err != nil {
+		return record.Offset, &ClientErrors.CreateFileError{Filename: record.LocalPath, Err: err}
+	}
+
+	segments := splitSegments(record.Offset, record.Size, grant.Streams)
+	errs := make([]error, len(segments))
+	var wg sync.WaitGroup
+	for i, part := range segments {
+		wg.Add(1)
+		go func(i int, part segment) {
+			defer wg.Done()
+			errs[i] = downloadSegment(transfer, file, record.LocalPath, grant, part)
+		}(i, part)
+	}
+	wg.Wait()
+	offset := completedOffset(record.Offset, segments, errs)
+	err = errors.Join(errs...)
+	if err == nil && offset != record.Size { // If the segments haven't covered the whole file
+		err = &ClientErrors.ShortTransferError{Filename: record.LocalPath, Expected: record.Size, Received: offset}
+	}
+	if err != nil {
+		return offset, err
+	}
+	return offset, commitDownload(file, record.LocalPath, record.Size, grant.Metadata)
+}
+
+// Receives one range of the file over its own socket and writes it in its place, then verifies its digest
+func downloadSegment(transfer *transferJob, file *os.File, path string, grant Requests.ChunkGrant, part segment) error {
+	if grant.ChunksSize <= 0 { // If the server hasn't granted chunks to receive the file with
+		return &ClientErrors.ServerBadChunks{}
+	}
+	socket, stop, err := openSegmentSocket(transfer, grant.TransferID, part)
+	if err != nil {
+		return err
+	}
+	defer (*socket).Close()
+	defer stop()
+
+	writer := bufio.NewWriter(io.NewOffsetWriter(file, part.Offset))
+	digest := sha256.New()
+	received, err := reciveFileBytes(socket, io.MultiWriter(writer, digest), path, grant.ChunksSize, part.Offset, part.Offset+part.Length)
+	if err != nil {
+		return err
+	}
+	if received != part.Offset+part.Length {
+		return &ClientErrors.ShortTransferError{Filename: path, Expected: part.Offset + part.Length, Received: received}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return &ClientErrors.CreateFileError{Filename: path, Err: err}
+	}
+
+	endOfFile, err := reciveEndOfFile(socket, path, part.Offset+part.Length)
+	if err != nil {
+		return err
+	}
+	return verifyDownload(endOfFile, path, digest.Sum(nil))
+}
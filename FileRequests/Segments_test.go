@@ -0,0 +1,115 @@
+package FileRequestsManager
+
+import (
+	"bytes"
+	"client/ClientErrors"
+	"client/Requests"
+	"client/TestServer"
+	"errors"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Downloads the file over the given amount of streams and returns the bytes the server has sent over every stream
+func streamedDownload(t *testing.T, server *TestServer.Server, socket *net.Conn, name string, streams string, data []byte) []int {
+	t.Helper()
+	dir := t.TempDir()
+	created := JobsCreated()
+	before := len(server.Streamed())
+	err := HandleDownloadFile([]string{"--streams=" + streams, name, dir}, socket)
+	if err == nil {
+		err = WaitForJobs(created)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatalf("downloaded file differs from the server's over %s streams", streams)
+	}
+	return server.Streamed()[before:]
+}
+
+func TestStreamsShareDownload(t *testing.T) {
+	server, socket := startSession(t)
+	data := make([]byte, 4*minSegmentSize)
+	for i := range data {
+		data[i] = byte(i / 1024)
+	}
+	server.WriteFile("bob", "big.bin", data)
+
+	if streamed := streamedDownload(t, server, socket, "big.bin", "1", data); len(streamed) != 1 || streamed[0] != len(data) {
+		t.Fatalf("a single stream has sent %v bytes of %d", streamed, len(data))
+	}
+	streamed := streamedDownload(t, server, socket, "big.bin", "4", data)
+	if len(streamed) != 4 {
+		t.Fatalf("the file has been sent over %d streams, want 4", len(streamed))
+	}
+	for _, sent := range streamed { // Every stream carries an equal share, so they all finish together
+		if sent != len(data)/4 {
+			t.Fatalf("the streams have sent %v bytes of %d", streamed, len(data))
+		}
+	}
+}
+
+func TestLargeSegments(t *testing.T) {
+	const size = 5 << 30
+	tests := []struct {
+		offset int64
+		count  int
+	}{
+		{0, segmentCount(size, Requests.MaxStreams)},
+		{math.MaxUint32 - 10, 4},
+		{math.MaxUint32 + 10, 3},
+	}
+	for _, test := range tests {
+		segments := splitSegments(test.offset, size, test.count)
+		if len(segments) != test.count {
+			t.Errorf("offset %d: %d segments, want %d", test.offset, len(segments), test.count)
+		}
+		next := test.offset
+		for _, part := range segments {
+			if part.Offset != next || part.Length <= 0 {
+				t.Fatalf("offset %d: segment %+v, want it to start at %d", test.offset, part, next)
+			}
+			next = part.Offset + part.Length
+		}
+		if next != size {
+			t.Errorf("offset %d: segments end at %d of %d", test.offset, next, int64(size))
+		}
+
+		errs := make([]error, len(segments))
+		if completed := completedOffset(test.offset, segments, errs); completed != size {
+			t.Errorf("offset %d: completed offset is %d of %d", test.offset, completed, int64(size))
+		}
+		errs[len(errs)-1] = errors.New("stream lost")
+		if completed := completedOffset(test.offset, segments, errs); completed != segments[len(segments)-1].Offset || completed <= math.MaxUint32 {
+			t.Errorf("offset %d: completed offset is %d after the last stream failed", test.offset, completed)
+		}
+	}
+}
+
+func TestSegmentsWithoutDownloadedBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "video.mp4")
+	os.WriteFile(partialPath(path), []byte("short"), 0o644) // The partial file has lost the bytes downloaded before
+	record := resumeRecord{LocalPath: path, Offset: 64, Size: 256}
+	grant := Requests.ChunkGrant{ChunksSize: 16, Size: 256, Streams: 2, TransferID: "transfer-1"}
+
+	offset, err := downloadSegments(nil, record, grant)
+	var short *ClientErrors.ShortTransferError
+	if !errors.As(err, &short) || offset != 0 {
+		t.Fatalf("downloadSegments returned %d, %v", offset, err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Fatal("the incomplete file has been committed")
+	}
+	if data, _ := os.ReadFile(partialPath(path)); string(data) != "short" {
+		t.Fatalf("the partial file has been changed to %q", data)
+	}
+}
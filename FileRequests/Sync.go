@@ -0,0 +1,716 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The sync command makes a local folder and a cloud folder hold the same files. Both trees are compared with the
+// state they had after their last sync, which is saved to the sync file: a file that has changed on one side only is
+// copied to the other side, and a file that has been deleted on one side only is deleted on the other side.
+// A file that has changed on both sides is a conflict, it's reported and left as it is on both sides.
+// Local files are compared by size and modification time, and only hashed when those have changed.
+
+var (
+	syncFile   string     // Path of the file the state of the synced folders is saved to, kept in memory when empty
+	syncLock   sync.Mutex // Guards the sync file
+	memorySync syncStore  // State of this session when there is no sync file
+)
+
+type syncAction int
+
+const (
+	syncKeep        syncAction = iota // Same on both sides, only its state is saved
+	syncUpload                        // File that is new or has changed locally
+	syncDownload                      // File that is new or has changed in the cloud
+	syncCreateCloud                   // Folder that is new locally
+	syncCreateLocal                   // Folder that is new in the cloud
+	syncDeleteCloud                   // Deleted locally
+	syncDeleteLocal                   // Deleted in the cloud
+	syncConflict                      // Changed on both sides, or changed on one side and deleted on the other
+	syncForget                        // Gone from both sides
+)
+
+// A file or a folder as both sides have had it after the last sync
+type syncEntry struct {
+	Dir     bool   `json:"dir,omitempty"`
+	Size    uint64 `json:"size,omitempty"`
+	ModTime int64  `json:"mod_time,omitempty"` // Local modification time in nanoseconds
+	Sha256  string `json:"sha256,omitempty"`
+}
+
+// A local folder synced with a cloud folder
+type syncFolder struct {
+	Local string               `json:"local"` // Absolute local path
+	Cloud string               `json:"cloud"` // Absolute cloud path
+	Files map[string]syncEntry `json:"files"` // By path relative to the folders, with "/" separators
+}
+
+type syncStore struct {
+	Folders []syncFolder `json:"folders"`
+}
+
+// A file or a folder of the local folder
+type localEntry struct {
+	dir     bool
+	size    uint64
+	modTime int64 // Nanoseconds
+}
+
+// What is done with a path of the synced folders
+type syncStep struct {
+	path   string // Relative to the folders, with "/" separators
+	action syncAction
+	hash   string // Digest both sides have of the file after the step
+	reason string // Why a conflict can't be synced
+}
+
+// A sync of a local folder and a cloud folder
+type folderSync struct {
+	local     string
+	cloud     string
+	options   Helper.IgnoreRules // Patterns of the command options
+	rules     Helper.IgnoreRules // With the patterns of the local ignore files, after the local folder has been walked
+	localTree map[string]localEntry
+	cloudTree map[string]Requests.TreeEntry
+	state     syncFolder
+	job       *transferJob // Job the transfers are counted and stopped with
+	socket    *net.Conn    // Command socket of the job
+}
+
+// Sets the file the state of the synced folders is saved to
+func SetSyncFile(path string) {
+	syncFile = path
+}
+
+// Loads the state of the synced folders. Must be called with the lock held
+func loadSyncStore() (syncStore, error) {
+	if syncFile == "" {
+		return syncStore{Folders: append([]syncFolder(nil), memorySync.Folders...)}, nil
+	}
+	var store syncStore
+	data, err := os.ReadFile(syncFile)
+	if errors.Is(err, os.ErrNotExist) { // If no folder has been synced yet
+		return store, nil
+	}
+	if err != nil {
+		return store, &ClientErrors.SyncFileError{Path: syncFile, Err: err}
+	}
+	err = json.Unmarshal(data, &store)
+	if err != nil {
+		return store, &ClientErrors.SyncFileError{Path: syncFile, Err: err}
+	}
+	return store, nil
+}
+
+// Returns the state of the folders after their last sync, empty if they haven't been synced
+func loadSyncFolder(local string, cloud string) (syncFolder, error) {
+	syncLock.Lock()
+	defer syncLock.Unlock()
+	store, err := loadSyncStore()
+	if err != nil {
+		return syncFolder{}, err
+	}
+	folder := syncFolder{Local: local, Cloud: cloud}
+	for _, saved := range store.Folders {
+		if saved.Local == local && saved.Cloud == cloud {
+			folder = saved
+			break
+		}
+	}
+	if folder.Files == nil {
+		folder.Files = make(map[string]syncEntry)
+	}
+	return folder, nil
+}
+
+// Saves the state of the folders
+func saveSyncFolder(folder syncFolder) error {
+	syncLock.Lock()
+	defer syncLock.Unlock()
+	store, err := loadSyncStore()
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := range store.Folders {
+		if store.Folders[i].Local == folder.Local && store.Folders[i].Cloud == folder.Cloud {
+			store.Folders[i] = folder
+			found = true
+		}
+	}
+	if !found {
+		store.Folders = append(store.Folders, folder)
+	}
+
+	if syncFile == "" {
+		memorySync = store
+		return nil
+	}
+	err = writeJSONFile(syncFile, store)
+	if err != nil {
+		return &ClientErrors.SyncFileError{Path: syncFile, Err: err}
+	}
+	return nil
+}
+
+// Handles sync command, syncs a local folder with a cloud folder in a job, which reports the conflicts when it's done
+func HandleSync(command_arguments []string) (string, error) {
+	rate, _, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	rules, command_arguments, err := takeIgnoreOptions(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	dirPath, cloudpath, err := takeFolderPaths(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	if !IsCurrentPathInitialized() || openSession == nil { // The job signs in with the user's credentials
+		return "", &ClientErrors.NotAuthenticatedError{Message: "please sign in first"}
+	}
+
+	socket, err := openSession()
+	if err != nil {
+		return "", err
+	}
+	folders, err := newFolderSync(dirPath, cloudpath, rules, &socket)
+	if err != nil {
+		socket.Close()
+		return "", err
+	}
+	transfer, err := newJob(syncJob, folders.local, cloudpath, 0, nil, rate)
+	if err != nil {
+		socket.Close()
+		return "", err
+	}
+	context.AfterFunc(transfer.ctx, func() {
+		socket.Close()
+	})
+	folders.job = transfer
+	transfer.run(func() (int64, error) {
+		defer socket.Close()
+		err := folders.readTrees()
+		if err != nil {
+			return 0, err
+		}
+		steps, err := folders.plan()
+		if err != nil {
+			return 0, err
+		}
+		summary, err := folders.apply(steps)
+		if err == nil {
+			printLine("%s", summary)
+		}
+		return 0, err
+	})
+	return fmt.Sprintf("Job %d is syncing %s with %s\n", transfer.ID, folders.local, cloudpath), nil
+}
+
+// Returns the local folder and the cloud folder of the sync and watch commands
+func takeFolderPaths(command_arguments []string) (string, string, error) {
+	if len(command_arguments) < operationArguments {
+		return "", "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command_arguments)), Expected: uint8(operationArguments)}
+	}
+	return command_arguments[oldFileName], cleanCloudPath(absoluteCloudPath(command_arguments[newFileName])), nil
+}
+
+// Reads the state of the last sync of the folders, a local folder that doesn't exist yet is created.
+// A local folder that has been synced before and is missing isn't created, it may be on a drive that isn't mounted.
+func newFolderSync(dirPath string, cloud string, rules Helper.IgnoreRules, socket *net.Conn) (*folderSync, error) {
+	local, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, &ClientErrors.ReadFileInfoError{Filename: dirPath, Err: err}
+	}
+	state, err := loadSyncFolder(local, cloud)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(local)
+	if os.IsNotExist(err) {
+		if len(state.Files) > 0 { // Every synced file would be deleted in the cloud
+			return nil, &ClientErrors.SyncRootMissingError{Path: local}
+		}
+		err = os.MkdirAll(local, os.ModePerm)
+		if err != nil {
+			return nil, &ClientErrors.CreateFolderError{Foldername: local, Err: err}
+		}
+		printLine("Created local folder %s\n", local)
+	} else if err != nil {
+		return nil, &ClientErrors.ReadFileInfoError{Filename: local, Err: err}
+	} else if !info.IsDir() {
+		return nil, &ClientErrors.NotDirectoryError{Path: local}
+	}
+	return &folderSync{local: local, cloud: cloud, options: rules, state: state, socket: socket}, nil
+}
+
+// Reads both trees, the cloud folder is created if it doesn't exist yet
+func (folders *folderSync) readTrees() error {
+	err := folders.readOrCreateCloudTree()
+	if err != nil {
+		return err
+	}
+	return folders.readLocalTree()
+}
+
+// Lists every file and folder under the cloud folder, the folder is created if it doesn't exist yet
+func (folders *folderSync) readOrCreateCloudTree() error {
+	err := folders.readCloudTree()
+	var notFound *ClientErrors.NotFoundError
+	if !errors.As(err, &notFound) {
+		return err
+	}
+	err = createCloudFolder(folders.cloud, folders.socket)
+	if err != nil {
+		return err
+	}
+	printLine("Created cloud folder %s\n", folders.cloud)
+	folders.cloudTree = make(map[string]Requests.TreeEntry)
+	return nil
+}
+
+// Lists every file and folder under the cloud folder
+func (folders *folderSync) readCloudTree() error {
+	entries, err := requestTree(folders.cloud, folders.socket)
+	if err != nil {
+		return err
+	}
+	folders.cloudTree = make(map[string]Requests.TreeEntry, len(entries))
+	for _, entry := range entries {
+		folders.cloudTree[entry.Path] = entry
+	}
+	return nil
+}
+
+// Lists every file and folder under the local folder that the ignore rules don't exclude
+func (folders *folderSync) readLocalTree() error {
+	var err error
+	folders.localTree, folders.rules, err = scanLocalTree(folders.local, folders.options)
+	return err
+}
+
+// Lists every file and folder under the local folder that the rules don't exclude, by their slash paths.
+// Returns the rules with the patterns of the folder's ignore files
+func scanLocalTree(dirPath string, options Helper.IgnoreRules) (map[string]localEntry, Helper.IgnoreRules, error) {
+	tree := make(map[string]localEntry)
+	rules := options.Clone()
+	err := walkRules(dirPath, &rules, func(_ string, relativePath string, contentInfo fs.DirEntry) error {
+		if relativePath == "." {
+			return nil
+		}
+		info, err := contentInfo.Info()
+		if err != nil {
+			return &ClientErrors.ReadFileInfoError{Filename: relativePath, Err: err}
+		}
+		entry := localEntry{dir: info.IsDir(), modTime: info.ModTime().UnixNano()}
+		if !entry.dir {
+			entry.size = uint64(info.Size())
+		}
+		tree[filepath.ToSlash(relativePath)] = entry
+		return nil
+	})
+	return tree, rules, err
+}
+
+// Compares every path of both trees and their last state. Returns the steps sorted by path
+func (folders *folderSync) plan() ([]syncStep, error) {
+	paths := make(map[string]bool)
+	for relativePath := range folders.localTree {
+		paths[relativePath] = true
+	}
+	for relativePath, entry := range folders.cloudTree {
+		if !excludedPath(folders.rules, relativePath, entry.IsDir) {
+			paths[relativePath] = true
+		}
+	}
+	for relativePath, entry := range folders.state.Files {
+		if !excludedPath(folders.rules, relativePath, entry.Dir) {
+			paths[relativePath] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for relativePath := range paths {
+		sorted = append(sorted, relativePath)
+	}
+	sort.Strings(sorted)
+
+	steps := make([]syncStep, 0, len(sorted))
+	for _, relativePath := range sorted {
+		step, err := folders.compare(relativePath)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	keepFolders(steps)
+	return steps, nil
+}
+
+// Decides what is done with a path by how each side has changed since the last sync
+func (folders *folderSync) compare(relativePath string) (syncStep, error) {
+	step := syncStep{path: relativePath}
+	local, inLocal := folders.localTree[relativePath]
+	cloud, inCloud := folders.cloudTree[relativePath]
+	last, synced := folders.state.Files[relativePath]
+
+	switch {
+	case !inLocal && !inCloud:
+		step.action = syncForget
+		return step, nil
+	case inLocal && inCloud && local.dir != cloud.IsDir:
+		step.action, step.reason = syncConflict, "is a folder on one side and a file on the other"
+		return step, nil
+	case inLocal && local.dir:
+		step.action = syncCreateCloud
+		if inCloud {
+			step.action = syncKeep
+		} else if synced {
+			step.action = syncDeleteLocal
+		}
+		return step, nil
+	case inCloud && cloud.IsDir:
+		step.action = syncCreateLocal
+		if synced {
+			step.action = syncDeleteCloud
+		}
+		return step, nil
+	}
+
+	localChanged := false
+	if inLocal {
+		var err error
+		step.hash, localChanged, err = folders.localChange(relativePath, local, last, synced)
+		if err != nil {
+			return step, err
+		}
+	}
+	cloudChanged := inCloud && (!synced || last.Dir || cloud.Sha256 != last.Sha256)
+
+	switch {
+	case inLocal && inCloud && step.hash == cloud.Sha256:
+		step.action = syncKeep
+	case inLocal && inCloud && localChanged && !cloudChanged:
+		step.action = syncUpload
+	case inLocal && inCloud && cloudChanged && !localChanged:
+		step.action, step.hash = syncDownload, cloud.Sha256
+	case inLocal && inCloud:
+		step.action, step.reason = syncConflict, "has changed on both sides"
+	case inLocal && !synced:
+		step.action = syncUpload
+	case inLocal && localChanged:
+		step.action, step.reason = syncConflict, "has changed locally and has been deleted in the cloud"
+	case inLocal:
+		step.action = syncDeleteLocal
+	case !synced:
+		step.action, step.hash = syncDownload, cloud.Sha256
+	case cloudChanged:
+		step.action, step.reason = syncConflict, "has changed in the cloud and has been deleted locally"
+	default:
+		step.action = syncDeleteCloud
+	}
+	return step, nil
+}
+
+// Returns the local file's digest and whether it has changed since the last sync.
+// A file with the size and modification time of the last sync hasn't changed, so it isn't hashed.
+func (folders *folderSync) localChange(relativePath string, local localEntry, last syncEntry, synced bool) (string, bool, error) {
+	if synced && !last.Dir && local.size == last.Size && local.modTime == last.ModTime {
+		return last.Sha256, false, nil
+	}
+	hash, err := hashFile(folders.localPath(relativePath))
+	if err != nil {
+		return "", false, err
+	}
+	return hash, !synced || last.Dir || hash != last.Sha256, nil
+}
+
+// A folder deleted on one side is only deleted on the other side with everything under it.
+// If anything under it is kept, the folder is created again instead.
+func keepFolders(steps []syncStep) {
+	for i := len(steps) - 1; i >= 0; i-- { // The folders under a folder are decided before it
+		action := steps[i].action
+		if action != syncDeleteLocal && action != syncDeleteCloud {
+			continue
+		}
+		prefix := steps[i].path + "/"
+		for _, step := range steps[i+1:] {
+			if strings.HasPrefix(step.path, prefix) && step.action != action && step.action != syncForget {
+				if action == syncDeleteLocal {
+					steps[i].action = syncCreateCloud
+				} else {
+					steps[i].action = syncCreateLocal
+				}
+				break
+			}
+		}
+	}
+}
+
+// Applies the steps and saves the new state. Folders are created first and deleted last, after the files in them.
+// Returns the summary of the sync with its conflicts and failures
+func (folders *folderSync) apply(steps []syncStep) (string, error) {
+	counts := make(map[syncAction]int)
+	var problems []string
+	var applyErr error
+	run := func(step syncStep) {
+		if folders.job.ctx.Err() != nil { // The job has been cancelled, local steps don't notice it on their own
+			applyErr = context.Cause(folders.job.ctx)
+			return
+		}
+		err := folders.applyStep(step)
+		if Helper.IsConnectionLost(err) { // The rest of the steps can't be sent
+			applyErr = err
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("Failed: %s: %s", step.path, strings.ReplaceAll(err.Error(), "\n", " ")))
+		} else {
+			counts[step.action]++
+			folders.updateState(step)
+		}
+	}
+
+	for _, step := range steps {
+		if applyErr == nil && (step.action == syncCreateCloud || step.action == syncCreateLocal) {
+			run(step)
+		}
+	}
+	for _, step := range steps {
+		if applyErr == nil && (step.action == syncUpload || step.action == syncDownload || step.action == syncKeep || step.action == syncForget) {
+			run(step)
+		}
+	}
+	for i := len(steps) - 1; i >= 0; i-- { // Deleted deepest first
+		if applyErr == nil && (steps[i].action == syncDeleteCloud || steps[i].action == syncDeleteLocal) {
+			run(steps[i])
+		}
+	}
+	for _, step := range steps {
+		if step.action == syncConflict {
+			counts[syncConflict]++
+			problems = append(problems, fmt.Sprintf("Conflict: %s %s", step.path, step.reason))
+		}
+	}
+
+	err := saveSyncFolder(folders.state)
+	if applyErr != nil {
+		return "", applyErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Synced %s with %s: %d uploaded, %d downloaded, %d folders created, %d deleted locally, %d deleted in the cloud, %d conflicts\n",
+		folders.local, folders.cloud, counts[syncUpload], counts[syncDownload], counts[syncCreateCloud]+counts[syncCreateLocal],
+		counts[syncDeleteLocal], counts[syncDeleteCloud], counts[syncConflict])
+	for _, problem := range problems {
+		builder.WriteString(problem + "\n")
+	}
+	return builder.String(), nil
+}
+
+// Applies a single step on the side that has to change
+func (folders *folderSync) applyStep(step syncStep) error {
+	localPath := folders.localPath(step.path)
+	cloudPath := folders.cloudPath(step.path)
+	switch step.action {
+	case syncCreateCloud:
+		return createCloudFolder(cloudPath, folders.socket)
+	case syncCreateLocal:
+		err := os.MkdirAll(localPath, os.ModePerm)
+		if err != nil {
+			return &ClientErrors.CreateFolderError{Foldername: localPath, Err: err}
+		}
+	case syncUpload:
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return &ClientErrors.ReadFileInfoError{Filename: localPath, Err: err}
+		}
+		err = folders.uploadFile(localPath, folders.cloudPath(path.Dir(step.path)), uint64(info.Size()))
+		if err != nil {
+			return err
+		}
+		printLine("Uploaded %s\n", step.path)
+	case syncDownload:
+		err := folders.downloadFile(cloudPath, localPath)
+		if err != nil {
+			return err
+		}
+		printLine("Downloaded %s\n", step.path)
+	case syncDeleteLocal:
+		err := os.Remove(localPath) // A folder is deleted after the files in it
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		printLine("Deleted local %s\n", step.path)
+	case syncDeleteCloud:
+		data, err := Helper.ConvertStringToBytes(cloudPath)
+		if err != nil {
+			return err
+		}
+		_, err = Requests.SendRequest(Requests.DeleteContentRequest, data, folders.socket)
+		if err != nil {
+			return err
+		}
+		printLine("Deleted cloud %s\n", step.path)
+	}
+	return nil
+}
+
+// Saves what both sides have of the path after its step has been applied
+func (folders *folderSync) updateState(step syncStep) {
+	switch step.action {
+	case syncDeleteCloud, syncDeleteLocal, syncForget:
+		delete(folders.state.Files, step.path)
+	case syncCreateCloud, syncCreateLocal:
+		folders.state.Files[step.path] = syncEntry{Dir: true}
+	case syncKeep, syncUpload, syncDownload:
+		info, err := os.Stat(folders.localPath(step.path))
+		if err != nil {
+			return
+		}
+		if info.IsDir() {
+			folders.state.Files[step.path] = syncEntry{Dir: true}
+			return
+		}
+		folders.state.Files[step.path] = syncEntry{Size: uint64(info.Size()), ModTime: info.ModTime().UnixNano(), Sha256: step.hash}
+	}
+}
+
+// Returns the shortest form of an absolute cloud path, so the same folder always has the same saved state
+func cleanCloudPath(cloudPath string) string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(cloudPath, rootPrefix), func(r rune) bool { return r == '\\' || r == '/' }) {
+		switch part {
+		case ".":
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return rootPrefix + "\\" + strings.Join(parts, "\\")
+}
+
+// Returns the local path of a path relative to the folders
+func (folders *folderSync) localPath(relativePath string) string {
+	return filepath.Join(folders.local, filepath.FromSlash(relativePath))
+}
+
+// Returns the absolute cloud path of a path relative to the folders
+func (folders *folderSync) cloudPath(relativePath string) string {
+	if relativePath == "." {
+		return folders.cloud
+	}
+	return strings.TrimSuffix(folders.cloud, "\\") + "\\" + strings.ReplaceAll(relativePath, "/", "\\")
+}
+
+// Limits a transmission socket with the global limit and the job's own limit, and counts it with the job.
+// The returned function stops watching the job
+func (folders *folderSync) throttle(socket *net.Conn, upload bool) func() bool {
+	return folders.job.attachDirection(socket, upload)
+}
+
+// Uploads a file to the cloud folder, replacing the file with its name
+func (folders *folderSync) uploadFile(localPath string, cloudFolder string, size uint64) error {
+	err := checkSize(localPath, size)
+	if err != nil {
+		return err
+	}
+	file := newContent(filepath.Base(localPath), cloudFolder, size)
+	if fileInfo, err := os.Stat(localPath); err == nil {
+		file.Metadata = readMetadata(fileInfo)
+	}
+	file_data, err := json.Marshal(file)
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{Err: err}
+	}
+	var grant Requests.ChunkGrant
+	err = Requests.SendTypedRequest(Requests.UploadFileRequest, file_data, folders.socket, &grant)
+	if err != nil {
+		return err
+	}
+
+	uploadSocket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		return err
+	}
+	defer (*uploadSocket).Close()
+	stop := folders.throttle(uploadSocket, true)
+	defer stop()
+	_, err = uploadFile(int64(size), 0, grant.ChunksSize, localPath, false, *uploadSocket)
+	return err
+}
+
+// Downloads a cloud file to the local path, replacing the local file
+func (folders *folderSync) downloadFile(cloudPath string, localPath string) error {
+	data, err := json.Marshal(downloadRequest{Data: cloudPath})
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{Err: err}
+	}
+	var grant Requests.ChunkGrant
+	err = Requests.SendTypedRequest(Requests.DownloadFileRequest, data, folders.socket, &grant)
+	if err != nil {
+		return err
+	}
+	err = grant.ValidateDownload()
+	if err != nil {
+		return &ClientErrors.UnexpectedResponeError{Expected: "download grant", Respone: fmt.Sprintf("%+v", grant), Err: err}
+	}
+	size, err := grantedSize(grant, cloudPath, folders.socket)
+	if err != nil {
+		return err
+	}
+
+	if grant.ChunksSize == 0 { // The server doesn't transmit empty files
+		file, err := os.Create(localPath)
+		if err != nil {
+			return &ClientErrors.CreateFileError{Filename: localPath, Err: err}
+		}
+		file.Close()
+		tryRestoreMetadata(localPath, grant.Metadata)
+		return nil
+	}
+	downloadSocket, err := Helper.CreatePrivateSocket()
+	if err != nil {
+		return err
+	}
+	defer (*downloadSocket).Close()
+	stop := folders.throttle(downloadSocket, false)
+	defer stop()
+	_, err = downloadFile(localPath, grant.ChunksSize, size, 0, grant.Metadata, true, downloadSocket)
+	if err != nil {
+		removePartial(localPath)
+	}
+	return err
+}
+
+// Creates a cloud folder, a folder that exists already is kept
+func createCloudFolder(cloudPath string, socket *net.Conn) error {
+	data, err := Helper.ConvertStringToBytes(cloudPath)
+	if err != nil {
+		return err
+	}
+	_, err = Requests.SendRequest(Requests.CreateFolderRequest, data, socket)
+	var exists *ClientErrors.AlreadyExistsError
+	if errors.As(err, &exists) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package FileRequestsManager
+
+import (
+	"client/Authentication"
+	"client/ClientErrors"
+	"client/Requests"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Runs the sync command and waits for its job, returns the job
+func runSync(t *testing.T, local string, cloud string) *transferJob {
+	t.Helper()
+	created := JobsCreated()
+	_, err := HandleSync([]string{local, cloud})
+	if err != nil {
+		t.Fatal(err)
+	}
+	WaitForJobs(created)
+	return createdJob(t, created, syncJob)
+}
+
+func TestSyncJob(t *testing.T) {
+	server, _ := startSession(t)
+	local := filepath.Join(t.TempDir(), "Docs")
+	os.Mkdir(local, 0o755)
+	os.WriteFile(filepath.Join(local, "notes.txt"), []byte("notes"), 0o644)
+
+	job := runSync(t, local, "Docs")
+	if job.State() != jobFinished {
+		t.Fatalf("sync job %s: %v", job.State(), job.err)
+	}
+	if stored, err := server.ReadFile("bob", "Docs/notes.txt"); err != nil || string(stored) != "notes" {
+		t.Fatalf("server stored %q: %v", stored, err)
+	}
+	if job.done.Load() == 0 {
+		t.Fatal("the job hasn't counted the uploaded bytes")
+	}
+}
+
+func TestSyncMissingRoot(t *testing.T) {
+	server, _ := startSession(t)
+	local := filepath.Join(t.TempDir(), "Drive")
+	os.Mkdir(local, 0o755)
+	os.WriteFile(filepath.Join(local, "notes.txt"), []byte("notes"), 0o644)
+	runSync(t, local, "Drive")
+
+	os.RemoveAll(local) // Like a drive that isn't mounted
+	var missing *ClientErrors.SyncRootMissingError
+	_, err := HandleSync([]string{local, "Drive"})
+	if !errors.As(err, &missing) {
+		t.Fatalf("sync of a missing folder: %v", err)
+	}
+	if !server.Exists("bob", "Drive/notes.txt") {
+		t.Fatal("the synced file has been deleted in the cloud")
+	}
+	if _, err := os.Stat(local); err == nil {
+		t.Fatal("the missing folder has been created")
+	}
+}
+
+func TestSyncWithoutTree(t *testing.T) {
+	server, socket := startSession(t)
+	server.SetLegacyTree(true)
+	err := Authentication.HandleSignIn([]string{"bob", "secret"}, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := runSync(t, t.TempDir(), "Docs")
+	var unsupported *ClientErrors.UnsupportedFeatureError
+	if !errors.As(job.err, &unsupported) {
+		t.Fatalf("sync against a server without tree listings: %v", job.err)
+	}
+	for _, requestType := range server.Received() {
+		if requestType == Requests.TreeRequest {
+			t.Fatal("a tree request has been sent to a server without tree listings")
+		}
+	}
+}
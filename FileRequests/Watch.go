@@ -0,0 +1,579 @@
+package FileRequestsManager
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/Requests"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The watch command keeps a cloud folder a backup of a local folder. It runs as a job with a command socket of its own,
+// so the user keeps working while the folder is watched. The folder's changes are seen with the platform's file
+// notifications, or by scanning the folder every few seconds where they aren't supported. Changes are gathered until
+// the folder has been quiet for a moment, then the folder is compared with how it has been when it was pushed last.
+// A file or a folder that has disappeared while one with the same size and modification time has appeared has been
+// renamed or moved, so it's renamed or moved in the cloud instead of being uploaded again.
+// When the connection to the server is lost the job signs in on a new socket and pushes the changes again.
+
+const (
+	watchQuiet        = 1 * time.Second  // How long the folder has to be quiet before its changes are pushed
+	watchMaxDelay     = 10 * time.Second // Longest time changes wait while the folder keeps changing, and until failed changes are retried
+	pollInterval      = 2 * time.Second  // How often the folder is scanned when it can't be watched
+	watchReconnectGap = 1 * time.Second  // Wait before reconnecting after the connection has been lost, doubled after every failed attempt
+)
+
+var openSession func() (net.Conn, error) // Opens a new command socket signed in as the user
+
+// Sets how jobs that send requests of their own open their command sockets
+func SetSessionOpener(open func() (net.Conn, error)) {
+	openSession = open
+}
+
+// Returns whether a watch job is running
+func IsWatching() bool {
+	for _, transfer := range sortedJobs() {
+		if transfer.kind == watchJob && transfer.State() == jobRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// Stops the running watch jobs the way the cancel command does
+func StopWatching() {
+	for _, transfer := range sortedJobs() {
+		if transfer.kind == watchJob {
+			transfer.cancel(errJobCancelled)
+		}
+	}
+}
+
+// Waits until the running watch jobs have been stopped
+func WaitForWatching() {
+	for _, transfer := range sortedJobs() {
+		if transfer.kind == watchJob {
+			<-transfer.finished
+		}
+	}
+}
+
+// Notifies about changes of the watched folder
+type changeWatcher interface {
+	Changes() <-chan struct{} // Closed when the watcher has stopped
+	Watch(dirPath string)     // Watches a folder that has been created
+	Close() error
+}
+
+// A local folder pushed to a cloud folder whenever it changes
+type folderWatch struct {
+	folders *folderSync
+	job     *transferJob
+	pushed  map[string]localEntry // The local folder as it has been pushed
+}
+
+// Changes of the local folder since it has been pushed
+type folderChanges struct {
+	created  []string          // Sorted, folders before the paths under them
+	modified []string          // Files only
+	deleted  []string          // Sorted
+	moved    map[string]string // Cloud paths to move, by their new paths
+}
+
+// Handles watch command, pushes the changes of a local folder to a cloud folder until the job is cancelled
+func HandleWatch(command_arguments []string) (string, error) {
+	rate, _, command_arguments, err := takeLimitOption(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	rules, command_arguments, err := takeIgnoreOptions(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	dirPath, cloudpath, err := takeFolderPaths(command_arguments)
+	if err != nil {
+		return "", err
+	}
+	info, err := checkContent(dirPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", &ClientErrors.NotDirectoryError{Path: dirPath}
+	}
+	local, err := filepath.Abs(dirPath)
+	if err != nil {
+		return "", &ClientErrors.ReadFileInfoError{Filename: dirPath, Err: err}
+	}
+	if !IsCurrentPathInitialized() || openSession == nil { // The job signs in with the user's credentials
+		return "", &ClientErrors.NotAuthenticatedError{Message: "please sign in first"}
+	}
+
+	socket, err := openSession()
+	if err != nil {
+		return "", err
+	}
+	transfer, err := newJob(watchJob, local, cloudpath, 0, nil, rate)
+	if err != nil {
+		socket.Close()
+		return "", err
+	}
+	context.AfterFunc(transfer.ctx, func() {
+		socket.Close()
+	})
+	watch := &folderWatch{
+		folders: &folderSync{local: local, cloud: cloudpath, options: rules, job: transfer, socket: &socket},
+		job:     transfer,
+	}
+	transfer.run(func() (int64, error) {
+		defer socket.Close()
+		return 0, watch.run()
+	})
+	return fmt.Sprintf("Job %d is watching %s, type \"cancel %d\" to stop it\n", transfer.ID, local, transfer.ID), nil
+}
+
+// Pushes the whole folder, then pushes its changes until the job is stopped
+func (watch *folderWatch) run() error {
+	watcher, err := newNativeWatcher()
+	if err != nil { // Fall back to scanning the folder
+		watcher = newPollWatcher(watch.folders.local, watch.folders.options)
+	}
+	defer watcher.Close()
+	watcher.Watch(watch.folders.local)
+
+	failed, err := watch.pushAll()
+	for Helper.IsConnectionLost(err) { // The whole folder is pushed again on the new socket
+		err = watch.reconnect()
+		if err == nil {
+			failed, err = watch.pushAll()
+		}
+	}
+	if watch.job.ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	printLine("Job %d has backed up %s, watching it for changes\n", watch.job.ID, watch.folders.local)
+
+	timer := time.NewTimer(watchMaxDelay)
+	var firstChange time.Time // Start of the changes that haven't been pushed, zero if there are none
+	if failed {               // Try the failed files again later
+		firstChange = time.Now()
+	} else {
+		timer.Stop()
+	}
+	for {
+		select {
+		case <-watch.job.ctx.Done():
+			return nil
+		case _, ok := <-watcher.Changes():
+			if !ok {
+				return nil
+			}
+			now := time.Now()
+			if firstChange.IsZero() {
+				firstChange = now
+			}
+			timer.Reset(min(watchQuiet, watchMaxDelay-now.Sub(firstChange)))
+		case <-timer.C:
+			firstChange = time.Time{}
+			created, failed, err := watch.push()
+			if Helper.IsConnectionLost(err) { // The changes that haven't been pushed are pushed on the new socket
+				err = watch.reconnect()
+				if watch.job.ctx.Err() != nil {
+					return nil
+				}
+				if err == nil {
+					firstChange = time.Now()
+					timer.Reset(0)
+					continue
+				}
+			}
+			if err != nil {
+				return err
+			}
+			for _, dirPath := range created {
+				watcher.Watch(watch.folders.localPath(dirPath))
+			}
+			if failed { // Try the failed changes again later
+				firstChange = time.Now()
+				timer.Reset(watchMaxDelay)
+			}
+		}
+	}
+}
+
+// Opens a new command socket after the connection to the server has been lost, waiting longer after every failed
+// attempt. Returns an error if the job has been stopped or the server has rejected the session
+func (watch *folderWatch) reconnect() error {
+	printLine("Job %d has lost the connection to the server, reconnecting\n", watch.job.ID)
+	gap := watchReconnectGap
+	for {
+		select {
+		case <-watch.job.ctx.Done():
+			return context.Cause(watch.job.ctx)
+		case <-time.After(gap):
+		}
+		socket, err := openSession()
+		if err == nil {
+			(*watch.folders.socket).Close()
+			*watch.folders.socket = socket
+			if watch.job.ctx.Err() != nil { // Cancelled while the old socket has been replaced
+				socket.Close()
+				return context.Cause(watch.job.ctx)
+			}
+			printLine("Job %d has reconnected to the server\n", watch.job.ID)
+			return nil
+		}
+		var connectionErr *ClientErrors.ServerConnectionError
+		if !Helper.IsConnectionLost(err) && !errors.As(err, &connectionErr) { // The server is reachable but rejected the session
+			return err
+		}
+		gap = min(gap*2, watchMaxDelay)
+	}
+}
+
+// Uploads the files and creates the folders the cloud folder doesn't have the same way.
+// Returns whether some of them have failed
+func (watch *folderWatch) pushAll() (bool, error) {
+	err := watch.folders.readOrCreateCloudTree()
+	if err == nil {
+		err = watch.folders.readLocalTree()
+	}
+	if err != nil {
+		return false, err
+	}
+	watch.pushed = make(map[string]localEntry, len(watch.folders.localTree))
+	startReporting()
+	defer watch.job.startFile("", 0)
+
+	failed := false
+	for _, relativePath := range sortedPaths(watch.folders.localTree) {
+		local := watch.folders.localTree[relativePath]
+		cloud, inCloud := watch.folders.cloudTree[relativePath]
+		var err error
+		switch {
+		case local.dir && !(inCloud && cloud.IsDir):
+			err = createCloudFolder(watch.folders.cloudPath(relativePath), watch.folders.socket)
+		case !local.dir && !(inCloud && cloud.Size == local.size && watch.sameHash(relativePath, cloud.Sha256)):
+			err = watch.upload(relativePath, local.size)
+		}
+		if Helper.IsConnectionLost(err) || watch.job.ctx.Err() != nil {
+			return false, err
+		}
+		if err != nil { // Pushed again with the next changes
+			printLine("Job %d couldn't push %s: %s\n", watch.job.ID, relativePath, err.Error())
+			failed = true
+			continue
+		}
+		watch.pushed[relativePath] = local
+	}
+	return failed, nil
+}
+
+// Returns whether the local file's digest is the given one
+func (watch *folderWatch) sameHash(relativePath string, digest string) bool {
+	hash, err := hashFile(watch.folders.localPath(relativePath))
+	return err == nil && hash == digest
+}
+
+// Pushes the changes of the folder since it has been pushed last.
+// Returns the folders that have been created locally, and whether some of the changes have failed
+func (watch *folderWatch) push() ([]string, bool, error) {
+	err := watch.folders.readLocalTree()
+	if err != nil {
+		return nil, false, err
+	}
+	current := watch.folders.localTree
+	changes := compareTrees(watch.pushed, current)
+	if len(changes.created)+len(changes.modified)+len(changes.deleted) == 0 {
+		return nil, false, nil
+	}
+	startReporting()
+	defer watch.job.startFile("", 0)
+
+	next := maps.Clone(current) // What the cloud has after the push, failed changes are left as they have been
+	failed := false
+	fail := func(relativePath string, err error) {
+		printLine("Job %d couldn't push %s: %s\n", watch.job.ID, relativePath, err.Error())
+		failed = true
+		for pushedPath, entry := range watch.pushed {
+			if pushedPath == relativePath || strings.HasPrefix(pushedPath, relativePath+"/") {
+				next[pushedPath] = entry
+			}
+		}
+		for currentPath := range current {
+			if _, ok := watch.pushed[currentPath]; !ok && (currentPath == relativePath || strings.HasPrefix(currentPath, relativePath+"/")) {
+				delete(next, currentPath)
+			}
+		}
+	}
+
+	deleted := make(map[string]bool)
+	deleteCloud := func(relativePath string) error {
+		data, err := Helper.ConvertStringToBytes(watch.folders.cloudPath(relativePath))
+		if err == nil {
+			_, err = Requests.SendRequest(Requests.DeleteContentRequest, data, watch.folders.socket)
+		}
+		if err == nil {
+			deleted[relativePath] = true
+			printLine("Job %d has deleted %s\n", watch.job.ID, relativePath)
+		} else if !Helper.IsConnectionLost(err) {
+			fail(relativePath, err)
+		}
+		return err
+	}
+	for _, relativePath := range changes.deleted { // A file that has become a folder or the other way around is replaced
+		if _, ok := current[relativePath]; ok {
+			if err := deleteCloud(relativePath); Helper.IsConnectionLost(err) {
+				return nil, false, err
+			}
+		}
+	}
+
+	var createdDirs []string
+	for _, relativePath := range changes.created { // Folders first, the moved and uploaded contents go in them
+		if !current[relativePath].dir {
+			continue
+		}
+		createdDirs = append(createdDirs, relativePath)
+		if _, ok := changes.moved[relativePath]; ok || underAny(relativePath, changes.moved) {
+			continue
+		}
+		err := createCloudFolder(watch.folders.cloudPath(relativePath), watch.folders.socket)
+		if Helper.IsConnectionLost(err) {
+			return nil, false, err
+		}
+		if err != nil {
+			fail(relativePath, err)
+		}
+	}
+
+	uploads := append([]string(nil), changes.modified...)
+	for _, relativePath := range changes.created {
+		if source, ok := changes.moved[relativePath]; ok {
+			err := watch.move(source, relativePath)
+			if Helper.IsConnectionLost(err) {
+				return nil, false, err
+			}
+			if err == nil {
+				printLine("Job %d has moved %s to %s\n", watch.job.ID, source, relativePath)
+				continue
+			}
+			delete(changes.moved, relativePath) // Push it as a new content instead
+			if current[relativePath].dir {
+				err = createCloudFolder(watch.folders.cloudPath(relativePath), watch.folders.socket)
+				if err != nil {
+					fail(relativePath, err)
+					continue
+				}
+			}
+		}
+		if !current[relativePath].dir && !underAny(relativePath, changes.moved) {
+			uploads = append(uploads, relativePath)
+		}
+	}
+	for _, relativePath := range uploads {
+		err := watch.upload(relativePath, current[relativePath].size)
+		if Helper.IsConnectionLost(err) || watch.job.ctx.Err() != nil {
+			return nil, false, err
+		}
+		if err != nil {
+			fail(relativePath, err)
+			continue
+		}
+		printLine("Job %d has uploaded %s\n", watch.job.ID, relativePath)
+	}
+
+	movedFrom := make(map[string]string, len(changes.moved))
+	for destination, source := range changes.moved {
+		movedFrom[source] = destination
+	}
+	for _, relativePath := range changes.deleted {
+		parent := path.Dir(relativePath)
+		_, parentDeleted := watch.pushed[parent]
+		if _, ok := current[parent]; ok || parent == "." {
+			parentDeleted = deleted[parent]
+		}
+		if deleted[relativePath] || parentDeleted || movedFrom[relativePath] != "" || underAny(relativePath, movedFrom) { // Deleted or moved with its folder
+			continue
+		}
+		if err := deleteCloud(relativePath); Helper.IsConnectionLost(err) {
+			return nil, false, err
+		}
+	}
+
+	watch.pushed = next
+	return createdDirs, failed, nil
+}
+
+// Uploads a local file to its cloud folder, as the job's current file
+func (watch *folderWatch) upload(relativePath string, size uint64) error {
+	watch.job.startFile(relativePath, int64(size))
+	return watch.folders.uploadFile(watch.folders.localPath(relativePath), watch.folders.cloudPath(path.Dir(relativePath)), size)
+}
+
+// Moves a cloud file or folder to its new path, and renames it if its name has changed
+func (watch *folderWatch) move(source string, destination string) error {
+	if path.Dir(source) != path.Dir(destination) {
+		data, err := json.Marshal(newPathPairRequest(watch.folders.cloudPath(source), watch.folders.cloudPath(path.Dir(destination))))
+		if err != nil {
+			return &ClientErrors.JsonEncodeError{Err: err}
+		}
+		_, err = Requests.SendRequest(Requests.MoveRequest, data, watch.folders.socket)
+		if err != nil {
+			return err
+		}
+		source = path.Join(path.Dir(destination), path.Base(source))
+	}
+	if path.Base(source) == path.Base(destination) {
+		return nil
+	}
+	data, err := json.Marshal(newPathPairRequest(watch.folders.cloudPath(source), path.Base(destination)))
+	if err != nil {
+		return &ClientErrors.JsonEncodeError{Err: err}
+	}
+	_, err = Requests.SendRequest(Requests.RenameRequest, data, watch.folders.socket)
+	return err
+}
+
+// Compares the pushed folder with the current one. A created path that has the size and modification time of a
+// deleted path is moved from it, a folder only if everything under it is the same.
+func compareTrees(pushed map[string]localEntry, current map[string]localEntry) folderChanges {
+	changes := folderChanges{moved: make(map[string]string)}
+	for _, relativePath := range sortedPaths(current) {
+		entry := current[relativePath]
+		old, ok := pushed[relativePath]
+		switch {
+		case !ok || old.dir != entry.dir:
+			changes.created = append(changes.created, relativePath)
+		case !entry.dir && (old.size != entry.size || old.modTime != entry.modTime):
+			changes.modified = append(changes.modified, relativePath)
+		}
+	}
+	for _, relativePath := range sortedPaths(pushed) {
+		if entry, ok := current[relativePath]; !ok || entry.dir != pushed[relativePath].dir {
+			changes.deleted = append(changes.deleted, relativePath)
+		}
+	}
+
+	sources := make(map[string]bool) // Deleted paths that have been matched already
+	for _, created := range changes.created {
+		if underAny(created, changes.moved) {
+			continue
+		}
+		signature := treeSignature(current, created)
+		for _, deleted := range changes.deleted {
+			if sources[deleted] || underAny(deleted, sourcesOf(changes.moved)) || treeSignature(pushed, deleted) != signature {
+				continue
+			}
+			changes.moved[created] = deleted
+			sources[deleted] = true
+			break
+		}
+	}
+	return changes
+}
+
+// Describes a path and everything under it by the sizes and modification times, relative to the path
+func treeSignature(tree map[string]localEntry, root string) string {
+	entry := tree[root]
+	if !entry.dir {
+		return fmt.Sprintf("file %d %d", entry.size, entry.modTime)
+	}
+	var lines []string
+	for relativePath, child := range tree {
+		if strings.HasPrefix(relativePath, root+"/") {
+			lines = append(lines, fmt.Sprintf("%s %t %d %d", strings.TrimPrefix(relativePath, root+"/"), child.dir, child.size, child.modTime))
+		}
+	}
+	sort.Strings(lines)
+	return "dir\n" + strings.Join(lines, "\n")
+}
+
+// Returns whether the path is under one of the folders the map is keyed by
+func underAny(relativePath string, folders map[string]string) bool {
+	for parent := path.Dir(relativePath); parent != "."; parent = path.Dir(parent) {
+		if _, ok := folders[parent]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the moved paths keyed by their sources
+func sourcesOf(moved map[string]string) map[string]string {
+	sources := make(map[string]string, len(moved))
+	for destination, source := range moved {
+		sources[source] = destination
+	}
+	return sources
+}
+
+// Returns the paths of a tree sorted, folders before the paths under them
+func sortedPaths(tree map[string]localEntry) []string {
+	paths := make([]string, 0, len(tree))
+	for relativePath := range tree {
+		paths = append(paths, relativePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Notifies about changes found by scanning the folder every few seconds
+type pollWatcher struct {
+	changes chan struct{}
+	cancel  context.CancelFunc
+}
+
+func newPollWatcher(dirPath string, options Helper.IgnoreRules) *pollWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := &pollWatcher{changes: make(chan struct{}, 1), cancel: cancel}
+	go func() {
+		defer close(watcher.changes)
+		last, _, _ := scanLocalTree(dirPath, options)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, _, err := scanLocalTree(dirPath, options)
+			if errors.Is(err, fs.ErrNotExist) { // The folder is gone for now, its files aren't deleted in the cloud
+				continue
+			}
+			if !maps.Equal(last, current) {
+				last = current
+				select {
+				case watcher.changes <- struct{}{}:
+				default: // A change is waiting already
+				}
+			}
+		}
+	}()
+	return watcher
+}
+
+func (watcher *pollWatcher) Changes() <-chan struct{} {
+	return watcher.changes
+}
+
+func (watcher *pollWatcher) Watch(dirPath string) {} // Every scan covers the whole folder
+
+func (watcher *pollWatcher) Close() error {
+	watcher.cancel()
+	return nil
+}
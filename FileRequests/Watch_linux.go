@@ -0,0 +1,99 @@
+package FileRequestsManager
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Changes of every watched folder, folders created in a watched folder are watched too
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// Notifies about changes with inotify
+type inotifyWatcher struct {
+	fd      int      // Used for adding watches, the file is only read
+	file    *os.File // Non-blocking, so closing it stops the read
+	changes chan struct{}
+
+	mu      sync.Mutex
+	folders map[int32]string // Watched folders by their watch descriptors
+}
+
+func newNativeWatcher() (changeWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	watcher := &inotifyWatcher{fd: fd, file: os.NewFile(uintptr(fd), "inotify"), changes: make(chan struct{}, 1), folders: make(map[int32]string)}
+	go watcher.read()
+	return watcher, nil
+}
+
+func (watcher *inotifyWatcher) Changes() <-chan struct{} {
+	return watcher.changes
+}
+
+// Watches the folder and every folder under it
+func (watcher *inotifyWatcher) Watch(dirPath string) {
+	filepath.WalkDir(dirPath, func(contentPath string, contentInfo fs.DirEntry, err error) error {
+		if err != nil || !contentInfo.IsDir() {
+			return nil
+		}
+		descriptor, err := syscall.InotifyAddWatch(watcher.fd, contentPath, inotifyMask)
+		if err == nil {
+			watcher.mu.Lock()
+			watcher.folders[int32(descriptor)] = contentPath
+			watcher.mu.Unlock()
+		}
+		return nil
+	})
+}
+
+func (watcher *inotifyWatcher) Close() error {
+	return watcher.file.Close()
+}
+
+// Reads the events until the watcher is closed, every batch of events is a change
+func (watcher *inotifyWatcher) read() {
+	defer close(watcher.changes)
+	buffer := make([]byte, 64*1024)
+	for {
+		read, err := watcher.file.Read(buffer)
+		if errors.Is(err, syscall.EINTR) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= read; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buffer[nameStart:nameStart+int(event.Len)], "\x00"))
+			offset = nameStart + int(event.Len)
+
+			if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				watcher.mu.Lock()
+				parent, ok := watcher.folders[event.Wd]
+				watcher.mu.Unlock()
+				if ok { // Watch the new folder before anything is written in it
+					watcher.Watch(filepath.Join(parent, name))
+				}
+			}
+			if event.Mask&syscall.IN_IGNORED != 0 { // The folder has been deleted
+				watcher.mu.Lock()
+				delete(watcher.folders, event.Wd)
+				watcher.mu.Unlock()
+			}
+		}
+		select {
+		case watcher.changes <- struct{}{}:
+		default: // A change is waiting already
+		}
+	}
+}
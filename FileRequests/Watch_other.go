@@ -0,0 +1,10 @@
+//go:build !linux
+
+package FileRequestsManager
+
+import "errors"
+
+// Only inotify is supported, other platforms scan the folder
+func newNativeWatcher() (changeWatcher, error) {
+	return nil, errors.New("file notifications aren't supported on this platform")
+}
@@ -0,0 +1,92 @@
+package FileRequestsManager
+
+import (
+	"client/Helper"
+	"client/Requests"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Waits until the server has the file, fails the test after the timeout
+func waitForFile(t *testing.T, exists func() bool, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !exists() {
+		if time.Now().After(deadline) {
+			t.Fatal("the file hasn't been pushed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestWatchReconnects(t *testing.T) {
+	server, _ := startSession(t)
+	local := t.TempDir()
+	os.WriteFile(filepath.Join(local, "first.txt"), []byte("first"), 0o644)
+
+	created := JobsCreated()
+	_, err := HandleWatch([]string{local, "Backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := createdJob(t, created, watchJob)
+	t.Cleanup(func() {
+		job.cancel(errJobCancelled)
+		<-job.finished
+	})
+	waitForFile(t, func() bool { return server.Exists("bob", "Backup/first.txt") }, 10*time.Second)
+	if err := WaitForJobs(created); err != nil || job.State() != jobRunning { // Watch jobs aren't waited for
+		t.Fatalf("waited for the watch job: %v", err)
+	}
+
+	server.CloseConnections() // Like a server restart
+	os.WriteFile(filepath.Join(local, "second.txt"), []byte("second"), 0o644)
+	waitForFile(t, func() bool { return server.Exists("bob", "Backup/second.txt") }, 20*time.Second)
+	if job.State() != jobRunning {
+		t.Fatal("the watch job has stopped")
+	}
+}
+
+func TestScanMissingFolder(t *testing.T) {
+	_, _, err := scanLocalTree(filepath.Join(t.TempDir(), "missing"), Helper.IgnoreRules{})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("scan of a missing folder: %v", err)
+	}
+}
+
+func TestWatchRenamesQuotedName(t *testing.T) {
+	server, _ := startSession(t)
+	local := t.TempDir()
+	os.WriteFile(filepath.Join(local, "Bob's notes.txt"), []byte("notes"), 0o644)
+
+	created := JobsCreated()
+	_, err := HandleWatch([]string{local, "Backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := createdJob(t, created, watchJob)
+	t.Cleanup(func() {
+		job.cancel(errJobCancelled)
+		<-job.finished
+	})
+	waitForFile(t, func() bool { return server.Exists("bob", "Backup/Bob's notes.txt") }, 10*time.Second)
+
+	os.Rename(filepath.Join(local, "Bob's notes.txt"), filepath.Join(local, "Bob's plans.txt"))
+	waitForFile(t, func() bool { return server.Exists("bob", "Backup/Bob's plans.txt") }, 10*time.Second)
+	if server.Exists("bob", "Backup/Bob's notes.txt") {
+		t.Fatal("the old name is still in the cloud")
+	}
+	uploads := 0
+	for _, requestType := range server.Received() {
+		if requestType == Requests.UploadFileRequest {
+			uploads++
+		}
+	}
+	if uploads != 1 {
+		t.Fatalf("the file has been uploaded %d times, want it renamed in the cloud", uploads)
+	}
+}
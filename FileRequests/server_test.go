@@ -0,0 +1,62 @@
+package FileRequestsManager
+
+import (
+	"client/Authentication"
+	"client/Helper"
+	"client/TestServer"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// Starts a test server and returns a socket signed in to it as bob.
+// Saved transfers and the sync state are kept in the test's temporary directory.
+func startSession(t *testing.T) (*TestServer.Server, *net.Conn) {
+	server, err := TestServer.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	server.AddUser("bob", "secret")
+
+	config := server.ClientConfig()
+	Helper.SetTLSOptions(Helper.TLSOptions{Insecure: config.TLSInsecure})
+	Helper.SetTransmissionAddr(config.TransmissionAddr)
+	Helper.SetTimeouts(config.DialTimeout.Duration, config.ResponseTimeout.Duration)
+	dir := t.TempDir()
+	SetResumeFile(filepath.Join(dir, "transfers.json"))
+	SetSyncFile(filepath.Join(dir, "sync.json"))
+	SetDownloadDir(dir)
+
+	socket, err := Helper.Dial(config.ServerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { socket.Close() })
+	err = Authentication.HandleSignIn([]string{"bob", "secret"}, &socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	InitializeCurrentPath()
+	SetSessionOpener(func() (net.Conn, error) { // Jobs with command sockets of their own
+		conn, err := Helper.Dial(config.ServerAddr)
+		if err != nil {
+			return nil, err
+		}
+		return conn, Authentication.Reauthenticate(&conn)
+	})
+	t.Cleanup(func() { SetSessionOpener(nil) })
+	return server, &socket
+}
+
+// Returns the job of the kind created after the given number of jobs
+func createdJob(t *testing.T, created int, kind jobKind) *transferJob {
+	t.Helper()
+	for _, transfer := range sortedJobs() {
+		if transfer.order > created && transfer.kind == kind {
+			return transfer
+		}
+	}
+	t.Fatalf("no %s job has been created", kind)
+	return nil
+}
@@ -3,7 +3,10 @@ package Handleinput
 import (
 	"bufio"
 	"client/Authentication"
+	"client/ClientErrors"
 	FileRequestsManager "client/FileRequests"
+	"client/Helper"
+	"fmt"
 	"net"
 	"os"
 	"strings"
@@ -15,7 +18,13 @@ const (
 )
 
 type UserInput struct {
-	Scanner *bufio.Scanner
+	Scanner     *bufio.Scanner
+	stopOnError bool // Set by "set -e", a script stops at the first command that fails
+}
+
+// Returns whether "set -e" has been given, so the commands stop at the first one that fails
+func (inputBuffer *UserInput) StopsOnError() bool {
+	return inputBuffer.stopOnError
 }
 
 func NewUserInput() *UserInput {
@@ -23,13 +32,19 @@ func NewUserInput() *UserInput {
 }
 
 // Scan user's input and convert it to text
-func (inputBuffer UserInput) readInput() string {
+func (inputBuffer *UserInput) readInput() string {
 	inputBuffer.Scanner.Scan()
 	command := inputBuffer.Scanner.Text()
 
 	return command
 }
 
+// Prints the question and returns the user's answer
+func (inputBuffer *UserInput) Ask(question string) string {
+	fmt.Print(question)
+	return inputBuffer.readInput()
+}
+
 func helpScreen() string {
 	return `
 SIGNUP		Create an account in CloudDrive service.
@@ -46,122 +61,177 @@ UPLOADFILE	Uploads a file to the current directory/given directory.
 DOWNLOADFILE	Downloads a file in the current program directory/given directory.
 UPLOADDIR	Uploads a directory to the current directory/given directory.
 DOWNLOADDIR	Downloads a directory to the current program directory/given directory.
+JOBS		Lists the uploads and downloads of this session.
+PAUSE		Pauses the given job, it can be continued with RESUME.
+CANCEL		Stops the given job or interrupted transfer.
+RESUME		Lists the interrupted transfers/Continues the given transfer.
+LIMIT		Displays/Changes the bandwidth limit of all transfers or of the given job.
+SYNC		Syncs a local directory with a cloud directory in both directions, in a job.
+WATCH		Backs up a local directory to a cloud directory whenever it changes, in a job until it's cancelled.
+SOURCE		Runs the commands of a script file, "set -e" in it stops it at the first failing command.
+
+Arguments with spaces or quotes in them can be enclosed in '' or "", or have them escaped with \.
+A # that starts an argument makes the rest of the line a comment.
 		`
 }
 
 //Gets user input and handles its command request.
+// Returns the command's output, or the error that stopped it.
+
+func (inputBuffer *UserInput) HandleInput(socket net.Conn) (string, error) {
+	command, err := Helper.SplitArguments(inputBuffer.readInput())
+	if err != nil {
+		return "", err
+	}
+	if len(command) > 0 && strings.ToLower(command[prefix_index]) == "set" { // Options of the input itself
+		return "", inputBuffer.setOptions(command[command_arguments:])
+	}
+	return Run(command, socket)
+}
+
+// Handles a single command line request.
+func Execute(line string, socket net.Conn) (string, error) {
+	command, err := Helper.SplitArguments(line) // Quoted and escaped arguments are kept whole
+	if err != nil {
+		return "", err
+	}
+	return Run(command, socket)
+}
 
-func (inputBuffer UserInput) HandleInput(socket net.Conn) string {
+// Handles a command that has been split to its arguments already, such as a one-shot command from the program's arguments.
+func Run(command []string, socket net.Conn) (string, error) {
 	var err error
-	command := strings.Fields(inputBuffer.readInput())
 	if len(command) > 0 { // If command is not empty
 		command_prefix := strings.ToLower(command[prefix_index])
 
 		switch command_prefix {
 
 		case "help":
-			return helpScreen()
+			return helpScreen(), nil
 
 		case "signup":
 			err = Authentication.HandleSignup(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
 
 			FileRequestsManager.InitializeCurrentPath()
-			return "Successfully signed up!\n"
+			return "Successfully signed up!\n", nil
 
 		case "signin":
 			err = Authentication.HandleSignIn(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
 
 			FileRequestsManager.InitializeCurrentPath()
-			return "Successfully signed in!\n"
+			return "Successfully signed in!\n", nil
 
 		case "cd":
 			err = FileRequestsManager.HandleChangeDirectory(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return ""
+			return "", nil
 
 		case "garbage":
 			err = FileRequestsManager.HandleGarbage(&socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return ""
+			return "", nil
 
 		case FileRequestsManager.CreateFileCommand, FileRequestsManager.CreateFolderCommand:
 			err = FileRequestsManager.HandleCreate(command, &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return "The content has been created successfully!\n"
+			return "The content has been created successfully!\n", nil
 
 		case "rm":
 			err = FileRequestsManager.HandleRemoveContent(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return "The content has been deleted successfully!\n"
+			return "The content has been deleted successfully!\n", nil
 
 		case "rename":
 			err = FileRequestsManager.HandleRename(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return "The content has been renamed!\n"
+			return "The content has been renamed!\n", nil
 
 		case "move":
 			err = FileRequestsManager.HandleMove(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return "The content has sucessfully moved!\n"
+			return "The content has sucessfully moved!\n", nil
 
 		case "ls":
 			dir, err := FileRequestsManager.HandleShow(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return dir
+			return dir, nil
 
 		case "uploadfile":
 			err = FileRequestsManager.HandleUploadFile(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return ""
+			return "", nil
 
 		case "downloadfile":
 			err = FileRequestsManager.HandleDownloadFile(command[command_arguments:], &socket)
 			if err != nil {
-				return err.Error()
+				return "", err
 			}
-			return ""
+			return "", nil
 
 		case "uploaddir":
-			err = FileRequestsManager.HandleUploadDirectory(command[command_arguments:], &socket)
-			if err != nil {
-				return err.Error()
-			}
-			return ""
+			return FileRequestsManager.HandleUploadDirectory(command[command_arguments:], &socket)
 
 		case "downloaddir":
-			err = FileRequestsManager.HandleDownloadDir(command[command_arguments:], &socket)
+			return FileRequestsManager.HandleDownloadDir(command[command_arguments:], &socket)
+
+		case "jobs":
+			return FileRequestsManager.HandleJobs(), nil
+
+		case "pause":
+			err = FileRequestsManager.HandlePause(command[command_arguments:])
 			if err != nil {
-				return err.Error()
+				return "", err
+			}
+			return "", nil
+
+		case "cancel":
+			return FileRequestsManager.HandleCancel(command[command_arguments:])
+
+		case "resume":
+			return FileRequestsManager.HandleResume(command[command_arguments:], &socket)
+
+		case "limit":
+			return FileRequestsManager.HandleLimit(command[command_arguments:])
+
+		case "sync":
+			return FileRequestsManager.HandleSync(command[command_arguments:])
+
+		case "watch":
+			return FileRequestsManager.HandleWatch(command[command_arguments:])
+
+		case "source":
+			if len(command) != command_arguments+1 {
+				return "", &ClientErrors.InvalidArgumentCountError{Arguments: uint8(len(command) - command_arguments), Expected: 1}
 			}
-			return ""
+			return "", Source(command[command_arguments], socket)
 
 		default:
-			return "Invalid command.\nPlease try a different command or use \"help\"\n"
+			return "", &ClientErrors.InvalidCommandError{Command: command_prefix}
 
 		}
 	}
-	return ""
+	return "", nil
 
 }
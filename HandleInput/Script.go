@@ -0,0 +1,77 @@
+package Handleinput
+
+import (
+	"bufio"
+	"client/ClientErrors"
+	FileRequestsManager "client/FileRequests"
+	"client/Helper"
+	"net"
+	"os"
+)
+
+const (
+	stopOnErrorOption     = "-e"
+	continueOnErrorOption = "+e"
+	maxSourceDepth        = 16 // Scripts that source each other, a script that sources itself stops here
+)
+
+var sourceDepth int // Scripts that are running, the ones they source included
+
+// Turns the options given to the set command on and off
+func (inputBuffer *UserInput) setOptions(options []string) error {
+	if len(options) == 0 {
+		return &ClientErrors.InvalidArgumentCountError{Arguments: 0, Expected: 1}
+	}
+	for _, option := range options {
+		switch option {
+		case stopOnErrorOption:
+			inputBuffer.stopOnError = true
+		case continueOnErrorOption:
+			inputBuffer.stopOnError = false
+		default:
+			return &ClientErrors.InvalidOptionError{Option: "set", Value: option}
+		}
+	}
+	return nil
+}
+
+// Runs every command of the script file through HandleInput, and prints their outputs and errors like the command line does.
+// Every command waits for the transfers it has started, so the next command sees their result. Watch jobs keep running.
+// The script stops if the connection to the server is lost, or with "set -e" at the first command that fails.
+// Returns the error that has stopped the script.
+func Source(path string, socket net.Conn) error {
+	if sourceDepth >= maxSourceDepth {
+		return &ClientErrors.SourceDepthError{Path: path, Depth: maxSourceDepth}
+	}
+	sourceDepth++
+	defer func() { sourceDepth-- }()
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ClientErrors.FileNotExistError{Filename: path}
+		}
+		return &ClientErrors.ReadFileInfoError{Filename: path, Err: err}
+	}
+	defer file.Close()
+
+	script := &UserInput{Scanner: bufio.NewScanner(file)}
+	for line := 1; ; line++ {
+		created := FileRequestsManager.JobsCreated()
+		output, err := script.HandleInput(socket)
+		if script.Scanner.Bytes() == nil { // If the whole script has been read
+			return script.Scanner.Err()
+		}
+		if err == nil {
+			err = FileRequestsManager.WaitForJobs(created)
+		}
+		if err != nil && (script.stopOnError || Helper.IsConnectionLost(err)) {
+			return &ClientErrors.ScriptError{Path: path, Line: line, Err: err}
+		}
+		if err != nil {
+			FileRequestsManager.PrintLine("%s\n", err.Error())
+		} else if output != "" {
+			FileRequestsManager.PrintLine("%s", output)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package Handleinput
+
+import (
+	"client/ClientErrors"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceDepth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loop.txt")
+	os.WriteFile(path, []byte("set -e\nsource '"+path+"'\n"), 0o644) // The script sources itself
+
+	err := Source(path, nil)
+	var depthErr *ClientErrors.SourceDepthError
+	if !errors.As(err, &depthErr) || depthErr.Depth != maxSourceDepth {
+		t.Fatalf("a script that sources itself has returned %v", err)
+	}
+	if sourceDepth != 0 {
+		t.Fatalf("%d scripts are still counted as running", sourceDepth)
+	}
+}
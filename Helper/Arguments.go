@@ -0,0 +1,85 @@
+package Helper
+
+import (
+	"client/ClientErrors"
+	"strings"
+	"unicode"
+)
+
+const (
+	singleQuote = '\''
+	doubleQuote = '"'
+	escape      = '\\'
+	comment     = '#'
+)
+
+// Splits a command line to its arguments like a POSIX shell does:
+// 'single quotes' keep everything in them as it is,
+// "double quotes" keep everything but \" and \\ as it is,
+// outside quotes a backslash escapes the next quote, backslash, # or space,
+// and a # that starts an argument makes the rest of the line a comment.
+// Backslashes before any other character are kept, so Windows and cloud paths can be typed without quotes.
+func SplitArguments(line string) ([]string, error) {
+	var arguments []string
+	var argument strings.Builder
+	inArgument := false // An argument has been started, even an empty quoted one
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		switch {
+		case unicode.IsSpace(char):
+			if inArgument {
+				arguments = append(arguments, argument.String())
+				argument.Reset()
+				inArgument = false
+			}
+			continue
+		case char == singleQuote:
+			end := indexRune(runes, i+1, singleQuote)
+			if end < 0 {
+				return nil, &ClientErrors.UnterminatedQuoteError{Quote: singleQuote}
+			}
+			argument.WriteString(string(runes[i+1 : end]))
+			i = end
+		case char == doubleQuote:
+			i++
+			for ; i < len(runes) && runes[i] != doubleQuote; i++ {
+				if runes[i] == escape && i+1 < len(runes) && (runes[i+1] == doubleQuote || runes[i+1] == escape) {
+					i++
+				}
+				argument.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &ClientErrors.UnterminatedQuoteError{Quote: doubleQuote}
+			}
+		case char == comment && !inArgument:
+			i = len(runes)
+			continue
+		case char == escape && i+1 < len(runes) && isEscapable(runes[i+1]):
+			i++
+			argument.WriteRune(runes[i])
+		default:
+			argument.WriteRune(char)
+		}
+		inArgument = true
+	}
+	if inArgument {
+		arguments = append(arguments, argument.String())
+	}
+	return arguments, nil
+}
+
+// Returns whether a backslash outside quotes escapes the character
+func isEscapable(char rune) bool {
+	return char == singleQuote || char == doubleQuote || char == escape || char == comment || unicode.IsSpace(char)
+}
+
+// Returns the index of the first char from start, -1 if it isn't found
+func indexRune(runes []rune, start int, char rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == char {
+			return i
+		}
+	}
+	return -1
+}
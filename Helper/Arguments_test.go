@@ -0,0 +1,69 @@
+package Helper
+
+import (
+	"client/ClientErrors"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitArguments(t *testing.T) {
+	tests := []struct {
+		line      string
+		arguments []string
+		quote     rune // Of the unterminated quote error, 0 if the line is split
+	}{
+		{line: "upload 'my notes.txt' \"new folder\"", arguments: []string{"upload", "my notes.txt", "new folder"}},
+		{line: "rename '' \"\" x", arguments: []string{"rename", "", "", "x"}},
+		{line: `mkdir "say \"hi\"" "back\\slash" "C:\temp"`, arguments: []string{"mkdir", `say "hi"`, `back\slash`, `C:\temp`}},
+		{line: `upload C:\Users\bob\notes.txt Root:\Docs`, arguments: []string{"upload", `C:\Users\bob\notes.txt`, `Root:\Docs`}},
+		{line: `upload my\ notes.txt \'quoted\'`, arguments: []string{"upload", "my notes.txt", "'quoted'"}},
+		{line: "'it''s' a'b'c", arguments: []string{"its", "abc"}},
+		{line: "ls # lists the folder", arguments: []string{"ls"}},
+		{line: "mkdir a#b '#c' \\#d", arguments: []string{"mkdir", "a#b", "#c", "#d"}},
+		{line: "# only a comment", arguments: nil},
+		{line: "  \t ", arguments: nil},
+		{line: "upload 'notes.txt", quote: '\''},
+		{line: `upload "notes.txt`, quote: '"'},
+		{line: `upload "notes.txt\"`, quote: '"'},
+	}
+	for _, test := range tests {
+		arguments, err := SplitArguments(test.line)
+		if test.quote != 0 {
+			var unterminated *ClientErrors.UnterminatedQuoteError
+			if !errors.As(err, &unterminated) || unterminated.Quote != test.quote {
+				t.Errorf("%q: split to %q, %v, want an unterminated %c quote", test.line, arguments, err, test.quote)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(arguments, test.arguments) {
+			t.Errorf("%q: split to %q, %v, want %q", test.line, arguments, err, test.arguments)
+		}
+	}
+}
+
+// Quotes the arguments so they are split back to the same arguments
+func quoteArguments(arguments []string) string {
+	quoted := make([]string, len(arguments))
+	for i, argument := range arguments {
+		quoted[i] = "'" + strings.ReplaceAll(argument, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+func FuzzSplitArguments(f *testing.F) {
+	for _, line := range []string{`upload 'my notes.txt' "a \"b\""`, `C:\Users\bob # comment`, `'' "" \ \#`, `"unterminated`} {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		arguments, err := SplitArguments(line)
+		if err != nil {
+			return
+		}
+		again, err := SplitArguments(quoteArguments(arguments))
+		if err != nil || !slices.Equal(again, arguments) {
+			t.Fatalf("%q split to %q, quoted again it's split to %q, %v", line, arguments, again, err)
+		}
+	})
+}
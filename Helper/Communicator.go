@@ -1,96 +1,98 @@
 package Helper
 
 import (
+	"bytes"
 	"client/ClientErrors"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
-	"os"
-	"strconv"
-	"strings"
-	"syscall"
-	"time"
 )
 
-type AmountOfPaths int
-
 const (
-	DefaultBufferSize                 = 1024
-	RemoveAll                         = -1
-	FirstNameParameter                = 0
-	SecondNameParameter               = 2
-	OneClosedPath       AmountOfPaths = 2 // Specifcy that looking for one filename argument that is closed with ''
-	TwoCloudPaths       AmountOfPaths = 4 // Specifcy that looking for two filename arguments that is closed with '' ''
-	secondPathIndex                   = 3
-	chunksIndex                       = 1
-	SkipEnclose                       = 1
-
-	transmissionAddr = "clouddriveserver.duckdns.org:12346"
+	DefaultBufferSize = 1024
+	messageHeaderSize = 4          // Length prefix size of every control message
+	maxMessageSize    = 0xFFFFFFFF // Biggest payload the length prefix can describe
 )
 
-// bufferSize is usually 1024
+// Messages on the control channel are framed with a length prefix:
+// [4 bytes big-endian payload length][payload bytes]
+// This way exactly one message is decoded per call no matter how TCP segments the stream.
 
 /*
-Recive data from client socket by the default buffer size.
+Recive one framed message from the socket.
+Reads the length prefix and then exactly that amount of payload bytes.
 
-Returns the received bytes.
+Returns the received message bytes.
 */
 func ReciveData(conn *net.Conn) (dataBytes []byte, errr error) {
-	buffer := make([]byte, DefaultBufferSize)
+	return ReciveDataLimited(conn, maxMessageSize)
+}
 
-	bytesRead, err := (*conn).Read(buffer)
-	if err != nil { // return custom error
-		return nil, fmt.Errorf("error when reciving a response from the server.\nPlease send this info to the developers:\n%s", err)
+// Recive one framed message from the socket, refusing messages bigger than maxSize bytes.
+// Used where a short message is expected, so unexpected bytes are not mistaken for a huge message.
+func ReciveDataLimited(conn *net.Conn, maxSize uint32) (dataBytes []byte, errr error) {
+	header := make([]byte, messageHeaderSize)
+	_, err := io.ReadFull(*conn, header) // Read the whole length prefix, even if it arrives in pieces
+	if err != nil {                      // return custom error
+		return nil, &ClientErrors.ReciveDataError{Err: err}
+	}
+	messageSize := binary.BigEndian.Uint32(header)
+	if messageSize > maxSize {
+		return nil, &ClientErrors.ReciveDataError{Err: fmt.Errorf("message of %d bytes is larger than the expected %d bytes", messageSize, maxSize)}
 	}
 
-	data := make([]byte, bytesRead)
-	copy(data, buffer[:bytesRead]) // Save all the actual data in a slice of bytes data
-	return data, nil
+	// Copy the payload as it arrives instead of allocating the announced size upfront
+	var message bytes.Buffer
+	_, err = io.CopyN(&message, *conn, int64(messageSize))
+	if err != nil { // If the connection ended before the whole message has arrived
+		return nil, &ClientErrors.ReciveDataError{Err: err}
+	}
+	return message.Bytes(), nil
+}
+
+// Add the length prefix to a message, so it can be sent as one frame
+func EncodeMessage(message []byte) []byte {
+	frame := make([]byte, messageHeaderSize+len(message))
+	binary.BigEndian.PutUint32(frame, uint32(len(message)))
+	copy(frame[messageHeaderSize:], message)
+	return frame
 }
 
 /*
-Send data to the client socket.
-Returns the received bytes.
+Send one framed message to the socket.
+The message is sent with its length prefix in a single write.
 */
 func SendData(conn *net.Conn, message []byte) error {
+	if uint64(len(message)) > maxMessageSize { // If the message can't be described by the length prefix
+		return &ClientErrors.SendDataError{Err: fmt.Errorf("message of %d bytes is too large to be sent", len(message))}
+	}
 
-	_, err := (*conn).Write(message)
-	if err != nil {
-		var syscallErr *os.SyscallError
-		switch errInfo := err.(type) {
-		case *net.OpError:
-			if errors.As(errInfo.Err, &syscallErr) { // If error belongs to syscall communication
-				if syscallErr.Syscall == "wsasend" && syscallErr.Err == syscall.WSAECONNRESET { // If error is that server is down
-					fmt.Println("Server has been closed.\nPlease try to reconnect in a few moments.")
-					os.Exit(1) // Shutdown the client program with an error indication
-				}
-			}
-		}
-
-		return fmt.Errorf("error when attempting to send the request to the server.\nPlease send this info to the developers:\n%s", err)
+	_, err := (*conn).Write(EncodeMessage(message))
+	if err != nil { // Use IsConnectionLost to check whether the server has dropped the connection
+		return &ClientErrors.SendDataError{Err: err}
 	}
 	return nil
 }
 
+// Recive up to bufferSize raw bytes from the socket.
+// Fails with TimeOutRespone if nothing arrives for the configured response timeout.
 func ReciveChunkData(conn *net.Conn, bufferSize int) (dataBytes []byte, errr error) {
 	buffer := make([]byte, bufferSize)
 
-	err := (*conn).SetReadDeadline(time.Now().Add(10 * time.Second)) // Set timeout for packet to recieve (10 seconds)
+	err := SetResponseDeadline(conn) // Set timeout for packet to recieve (configured response timeout)
 	if err != nil {
-		return nil, fmt.Errorf("it took too long time to get a respone back from the server")
+		return nil, &ClientErrors.ReciveDataError{Err: err}
 	}
 	bytesRead, err := (*conn).Read(buffer)
 	if err != nil {
-		switch err := err.(type) {
-		case *net.OpError:
-			if err.Timeout() { // If error is reciving timeout
-				return nil, err // return error as is
-			}
-		default: // return custom error
-			return nil, fmt.Errorf("error when reciving a response from the server.\nPlease send this info to the developers:\n%s", err)
-
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() { // If error is reciving timeout
+			return nil, &ClientErrors.TimeOutRespone{}
 		}
+		return nil, &ClientErrors.ReciveDataError{Err: err}
 	}
 	data := make([]byte, bytesRead)
 	copy(data, buffer[:bytesRead]) // Save all the actual data in a slice of bytes data
@@ -112,69 +114,11 @@ func ConvertStringToBytes(data string) ([]byte, error) {
 	return bytes, nil
 }
 
-// Find if the parameters are closed with ' in the amount of the given closedCount (for example, given TwoClosedPath: 'filename1' 'filename2')
-func IsQuoted(command_arguments []string, closedCount AmountOfPaths) bool {
-	arguments := strings.Join(command_arguments, " ")
-	var counter AmountOfPaths = 0
-	for _, char := range arguments {
-		if char == '\'' {
-			counter++
-		}
-	}
-	return counter == closedCount
-}
-
-// Find the path of a file if it's closed with ' with the given amount of closed paths and specific filename index.
-// Returns the string path with enclouse '
-func FindPath(command_arguments []string, index int, closedCount AmountOfPaths) string {
-	arguments := strings.Join(command_arguments, " ") // Convert to string
-	var name string
-	var counter int // counting the amount of '
-	for _, char := range arguments {
-		if char == '\'' { // If close ' char found
-			counter++
-		}
-		name += string(char)    // append char to string
-		if counter >= index+2 { // If 2/4 ' close chars have been found
-			break // Stop searching
-		}
-	}
-	if index == SecondNameParameter { // If the second path has been chosen
-		parts := strings.Split(name, "'")
-		// If the client provided 2 paths (two single quotes), save the second part
-		if len(parts) >= int(TwoCloudPaths) {
-			name = parts[secondPathIndex]
-		} else {
-			name = ""
-		}
-		//name = "'" + strings.Join((strings.Split(name, "'")[1])) + "'" // save the second path only. (int[closedCount]-1) to access the last index of the closedCount path
-	}
-	return name
-}
-
-// For cases when the first path is quoted but the second doesn't, return the second Non-quoted second path
-func ReturnNonQuotedSecondPath(command_arguments []string) string {
-	arguments := strings.Join(command_arguments, " ") // Convert the comman arguments to string
-	lastIndex := strings.LastIndex(arguments, "'")    // Find the last time the ' index encloused has been used in the string and append 1 to avoid it completely
-	if lastIndex+1 <= len(arguments) {                // If Second path hasn't been specified
-		return ""
-	}
-	return arguments[lastIndex+1:]
-}
-
 // Creates a private socket connection between the server for file transmission
 func CreatePrivateSocket() (*net.Conn, error) {
-	sock, err := net.Dial("tcp", transmissionAddr)
+	sock, err := Dial(transmissionAddr)
 	if err != nil {
-		fmt.Println(err.Error())
-		return nil, &ClientErrors.ServerConnectionError{Err: err}
+		return nil, err
 	}
 	return &sock, nil
 }
-
-// Convert respone to chunks size
-func ConvertResponeToChunks(respone string) (int, error) {
-	splited := strings.Split(respone, ":")
-
-	return strconv.Atoi(splited[chunksIndex])
-}
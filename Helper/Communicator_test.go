@@ -0,0 +1,117 @@
+package Helper
+
+import (
+	"bytes"
+	"client/ClientErrors"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+)
+
+// Returns both ends of an in-memory connection
+func pipe(t *testing.T) (net.Conn, net.Conn) {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestFramingRoundTrip(t *testing.T) {
+	messages := [][]byte{
+		[]byte(`{"Type":200,"Data":"ok"}`),
+		{},
+		bytes.Repeat([]byte("x"), 3*DefaultBufferSize+7), // Bigger than the old fixed buffer
+	}
+	client, server := pipe(t)
+
+	go func() { // All the messages are sent in one write, so TCP could deliver them merged
+		var stream []byte
+		for _, message := range messages {
+			stream = append(stream, EncodeMessage(message)...)
+		}
+		server.Write(stream)
+	}()
+	for i, message := range messages {
+		received, err := ReciveData(&client)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if !bytes.Equal(received, message) {
+			t.Fatalf("message %d: got %d bytes, want %d bytes", i, len(received), len(message))
+		}
+	}
+}
+
+func TestFramingSplitWrites(t *testing.T) {
+	message := []byte(`{"Type":200,"Data":"a message that arrives one byte at a time"}`)
+	client, server := pipe(t)
+
+	go func() {
+		for _, b := range EncodeMessage(message) {
+			server.Write([]byte{b})
+		}
+	}()
+	received, err := ReciveData(&client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, message) {
+		t.Fatalf("got %q, want %q", received, message)
+	}
+}
+
+func TestFramingSendData(t *testing.T) {
+	client, server := pipe(t)
+	go SendData(&server, []byte("hello"))
+
+	received, err := ReciveData(&client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(received) != "hello" {
+		t.Fatalf("got %q", received)
+	}
+}
+
+func TestFramingOversizePrefix(t *testing.T) {
+	client, server := pipe(t)
+	go func() {
+		header := make([]byte, messageHeaderSize)
+		binary.BigEndian.PutUint32(header, 1<<20)
+		server.Write(header)
+	}()
+
+	_, err := ReciveDataLimited(&client, 1024)
+	var reciveErr *ClientErrors.ReciveDataError
+	if !errors.As(err, &reciveErr) {
+		t.Fatalf("got %v, want ReciveDataError", err)
+	}
+}
+
+func TestFramingShortRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes []byte
+	}{
+		{"half header", []byte{0, 0}},
+		{"short payload", append(binary.BigEndian.AppendUint32(nil, 10), "abc"...)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := pipe(t)
+			go func() {
+				server.Write(test.bytes)
+				server.Close() // The connection ends in the middle of the message
+			}()
+
+			_, err := ReciveData(&client)
+			var reciveErr *ClientErrors.ReciveDataError
+			if !errors.As(err, &reciveErr) {
+				t.Fatalf("got %v, want ReciveDataError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package Helper
+
+import (
+	"bytes"
+	"client/ClientErrors"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLS settings used for both the command socket and the transmission sockets
+type TLSOptions struct {
+	CAFile    string // PEM bundle of trusted certificate authorities (system roots are used when empty)
+	PinSHA256 string // Hex SHA-256 fingerprint of the server's certificate. Connection is refused on mismatch
+	Insecure  bool   // Skip certificate verification. Only meant for testing against local servers
+}
+
+var (
+	tlsOptions       TLSOptions
+	transmissionAddr string             // Address of the file transmission sockets
+	dialTimeout      = 10 * time.Second // How long to wait for a connection to the server
+	responseTimeout  = 10 * time.Second // How long to wait for data while transferring files
+)
+
+// Sets the TLS settings for every new connection to the server
+func SetTLSOptions(options TLSOptions) {
+	tlsOptions = options
+}
+
+// Sets the address the private transmission sockets connect to
+func SetTransmissionAddr(addr string) {
+	transmissionAddr = addr
+}
+
+// Sets the connection and the transmission timeouts
+func SetTimeouts(dial time.Duration, response time.Duration) {
+	dialTimeout = dial
+	responseTimeout = response
+}
+
+// Makes the next reads of the socket fail if nothing arrives for the configured response timeout
+func SetResponseDeadline(conn *net.Conn) error {
+	return (*conn).SetReadDeadline(time.Now().Add(responseTimeout))
+}
+
+// Removes the read deadline of the socket, so it can wait for data as long as needed
+func ClearDeadline(conn *net.Conn) error {
+	return (*conn).SetReadDeadline(time.Time{})
+}
+
+// Builds the tls config for connecting to the given address
+func newTLSConfig(addr string) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: tlsOptions.Insecure,
+	}
+
+	if tlsOptions.CAFile != "" { // If a custom CA bundle has been configured
+		bundle, err := os.ReadFile(tlsOptions.CAFile)
+		if err != nil {
+			return nil, &ClientErrors.TLSConfigError{Err: err}
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) { // If the file doesn't contain any PEM certificate
+			return nil, &ClientErrors.TLSConfigError{Err: &ClientErrors.BadFileContent{Filename: tlsOptions.CAFile}}
+		}
+		config.RootCAs = pool
+	}
+
+	if tlsOptions.PinSHA256 != "" { // If the server's certificate is pinned, check it after the regular verification
+		pin, err := hex.DecodeString(strings.ReplaceAll(tlsOptions.PinSHA256, ":", ""))
+		if err != nil {
+			return nil, &ClientErrors.TLSConfigError{Err: err}
+		}
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return &ClientErrors.CertificatePinError{Expected: tlsOptions.PinSHA256}
+			}
+			fingerprint := sha256.Sum256(state.PeerCertificates[0].Raw) // Fingerprint of the server's leaf certificate
+			if !bytes.Equal(fingerprint[:], pin) {
+				return &ClientErrors.CertificatePinError{Expected: tlsOptions.PinSHA256, Got: hex.EncodeToString(fingerprint[:])}
+			}
+			return nil
+		}
+	}
+	return config, nil
+}
+
+// Opens a TLS connection to the server in the given address
+func Dial(addr string) (net.Conn, error) {
+	config, err := newTLSConfig(addr)
+	if err != nil {
+		return nil, err
+	}
+	sock, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", addr, config)
+	if err != nil {
+		return nil, &ClientErrors.ServerConnectionError{Err: err}
+	}
+	return sock, nil
+}
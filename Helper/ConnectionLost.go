@@ -0,0 +1,25 @@
+package Helper
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Returns whether the error means the connection to the server is dead (on any OS),
+// meaning every following request on the same socket would fail as well.
+func IsConnectionLost(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF): // Server closed the connection
+		return true
+	case errors.Is(err, net.ErrClosed): // Socket has been closed on the client side
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE):
+		return true
+	}
+	return isPlatformConnectionLost(err)
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package Helper
+
+// The generic syscall errors already cover every dropped connection outside of Windows
+func isPlatformConnectionLost(err error) bool {
+	return false
+}
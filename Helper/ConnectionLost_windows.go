@@ -0,0 +1,11 @@
+package Helper
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Winsock reports dropped connections with its own error codes
+func isPlatformConnectionLost(err error) bool {
+	return errors.Is(err, syscall.WSAECONNRESET) || errors.Is(err, syscall.WSAECONNABORTED)
+}
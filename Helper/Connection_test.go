@@ -0,0 +1,96 @@
+package Helper_test
+
+import (
+	"client/ClientErrors"
+	"client/Helper"
+	"client/TestServer"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Starts a test server with a fresh self-signed certificate
+func startServer(t *testing.T) *TestServer.Server {
+	server, err := TestServer.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+		Helper.SetTLSOptions(Helper.TLSOptions{})
+	})
+	return server
+}
+
+// Returns the hex SHA-256 fingerprint of the server's certificate
+func fingerprint(t *testing.T, server *TestServer.Server) string {
+	block, _ := pem.Decode(server.CertificatePEM())
+	if block == nil {
+		t.Fatal("server certificate isn't PEM")
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDialTLS(t *testing.T) {
+	server := startServer(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, server.CertificatePEM(), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherServer := startServer(t) // Has a certificate of its own
+
+	tests := []struct {
+		name    string
+		options Helper.TLSOptions
+		wantErr bool
+	}{
+		{"system roots don't trust a self-signed certificate", Helper.TLSOptions{}, true},
+		{"CA bundle", Helper.TLSOptions{CAFile: caFile}, false},
+		{"insecure", Helper.TLSOptions{Insecure: true}, false},
+		{"matching pin", Helper.TLSOptions{Insecure: true, PinSHA256: fingerprint(t, server)}, false},
+		{"CA bundle and matching pin", Helper.TLSOptions{CAFile: caFile, PinSHA256: fingerprint(t, server)}, false},
+		{"pin of another certificate", Helper.TLSOptions{Insecure: true, PinSHA256: fingerprint(t, otherServer)}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			Helper.SetTLSOptions(test.options)
+			conn, err := Helper.Dial(server.Addr())
+			if err == nil {
+				conn.Close()
+			}
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestDialPinMismatchError(t *testing.T) {
+	server := startServer(t)
+	Helper.SetTLSOptions(Helper.TLSOptions{Insecure: true, PinSHA256: hex.EncodeToString(make([]byte, sha256.Size))})
+
+	_, err := Helper.Dial(server.Addr())
+	var pinErr *ClientErrors.CertificatePinError
+	if !errors.As(err, &pinErr) {
+		t.Fatalf("got %v, want CertificatePinError", err)
+	}
+}
+
+func TestDialBadCAFile(t *testing.T) {
+	server := startServer(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, []byte("not a certificate"), 0600)
+	Helper.SetTLSOptions(Helper.TLSOptions{CAFile: caFile})
+
+	_, err := Helper.Dial(server.Addr())
+	var configErr *ClientErrors.TLSConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("got %v, want TLSConfigError", err)
+	}
+}
@@ -0,0 +1,168 @@
+package Helper
+
+import (
+	"bufio"
+	"client/ClientErrors"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Gitignore-style patterns that choose which paths of a directory are transferred.
+// Paths are relative to the transferred directory and use "/" separators.
+// The last pattern that matches a path decides, a "!" pattern includes a path an earlier pattern has excluded.
+
+const (
+	commentPrefix = "#"
+	negatePrefix  = "!"
+	dirSuffix     = "/"
+	anyDepth      = "**"
+)
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool // Includes the paths it matches
+	dirOnly bool // Only matches directories
+}
+
+type IgnoreRules struct {
+	fileRules   []ignoreRule // Patterns of the ignore files, in the order they have been read
+	optionRules []ignoreRule // Patterns of the command options, they override the ignore files
+}
+
+// Excludes the paths that match the pattern, whatever the ignore files say
+func (rules *IgnoreRules) Exclude(pattern string) error {
+	rule, ok, err := parseRule(pattern, "")
+	if ok {
+		rules.optionRules = append(rules.optionRules, rule)
+	}
+	return err
+}
+
+// Includes the paths that match the pattern, whatever the ignore files and the excludes say
+func (rules *IgnoreRules) Include(pattern string) error {
+	rule, ok, err := parseRule(pattern, "")
+	if ok {
+		rule.negate = true
+		rules.optionRules = append(rules.optionRules, rule)
+	}
+	return err
+}
+
+// Reads the patterns of an ignore file, they apply to the paths under base (the file's directory)
+func (rules *IgnoreRules) ReadFile(path string, base string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return &ClientErrors.ReadFileInfoError{Filename: path, Err: err}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		rule, ok, err := parseRule(scanner.Text(), base)
+		if err != nil {
+			return err
+		}
+		if ok {
+			rules.fileRules = append(rules.fileRules, rule)
+		}
+	}
+	if scanner.Err() != nil {
+		return &ClientErrors.ReadFileInfoError{Filename: path, Err: scanner.Err()}
+	}
+	return nil
+}
+
+// Returns a copy of the rules that ignore files can be added to without changing the original
+func (rules IgnoreRules) Clone() IgnoreRules {
+	return IgnoreRules{
+		fileRules:   append([]ignoreRule(nil), rules.fileRules...),
+		optionRules: append([]ignoreRule(nil), rules.optionRules...),
+	}
+}
+
+// Returns whether the path is excluded, paths under an excluded directory are never walked
+func (rules IgnoreRules) Excluded(path string, isDir bool) bool {
+	excluded := false
+	for _, list := range [][]ignoreRule{rules.fileRules, rules.optionRules} {
+		for _, rule := range list {
+			if (!rule.dirOnly || isDir) && rule.pattern.MatchString(path) {
+				excluded = !rule.negate
+			}
+		}
+	}
+	return excluded
+}
+
+// Parses a line of an ignore file. Returns false for blank lines and comments
+func parseRule(text string, base string) (ignoreRule, bool, error) {
+	line := strings.TrimRight(text, " \t\r")
+	if line == "" || strings.HasPrefix(line, commentPrefix) {
+		return ignoreRule{}, false, nil
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, negatePrefix) {
+		rule.negate = true
+		line = line[len(negatePrefix):]
+	}
+	if strings.HasPrefix(line, `\`+commentPrefix) || strings.HasPrefix(line, `\`+negatePrefix) { // Starts with a literal character
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, dirSuffix) {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, dirSuffix)
+	}
+	anchored := strings.Contains(line, "/") // A pattern with a slash is relative to its base, otherwise it matches at any depth
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false, &ClientErrors.InvalidPatternError{Pattern: text}
+	}
+
+	prefix := ""
+	if base != "" && base != "." {
+		prefix = regexp.QuoteMeta(strings.TrimSuffix(base, "/") + "/")
+	}
+	if !anchored {
+		prefix += "(?:.*/)?"
+	}
+	pattern, err := regexp.Compile("^" + prefix + patternRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false, &ClientErrors.InvalidPatternError{Pattern: text}
+	}
+	rule.pattern = pattern
+	return rule, true, nil
+}
+
+// Converts a glob pattern to a regular expression. "*" and "?" don't match "/", "**" matches any amount of directories
+func patternRegexp(pattern string) string {
+	var builder strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		rest := pattern[i:]
+		switch {
+		case strings.HasPrefix(rest, anyDepth+"/"): // Zero or more directories
+			builder.WriteString("(?:.*/)?")
+			i += len(anyDepth)
+		case strings.HasPrefix(rest, anyDepth):
+			builder.WriteString(".*")
+			i += len(anyDepth) - 1
+		case rest[0] == '*':
+			builder.WriteString("[^/]*")
+		case rest[0] == '?':
+			builder.WriteString("[^/]")
+		case rest[0] == '\\' && len(rest) > 1:
+			builder.WriteString(regexp.QuoteMeta(rest[1:2]))
+			i++
+		case rest[0] == '[' && strings.Contains(rest, "]"):
+			class := rest[1 : strings.Index(rest[1:], "]")+1]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += len(class) + 1
+		default:
+			builder.WriteString(regexp.QuoteMeta(rest[:1]))
+		}
+	}
+	return builder.String()
+}
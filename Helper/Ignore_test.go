@@ -0,0 +1,177 @@
+package Helper
+
+import "testing"
+
+// Returns the rules of an ignore file in the transferred directory with the given lines
+func fileRules(t *testing.T, lines ...string) IgnoreRules {
+	t.Helper()
+	var rules IgnoreRules
+	for _, line := range lines {
+		rule, ok, err := parseRule(line, "")
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if ok {
+			rules.fileRules = append(rules.fileRules, rule)
+		}
+	}
+	return rules
+}
+
+func TestPatternRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		regexp  string
+	}{
+		{pattern: "*.go", regexp: `[^/]*\.go`},
+		{pattern: "a?c", regexp: `a[^/]c`},
+		{pattern: "**/temp", regexp: `(?:.*/)?temp`},
+		{pattern: "logs/**", regexp: `logs/.*`},
+		{pattern: "a/**/b", regexp: `a/(?:.*/)?b`},
+		{pattern: "[abc].txt", regexp: `[abc]\.txt`},
+		{pattern: "[!abc]", regexp: `[^abc]`},
+		{pattern: `\*`, regexp: `\*`},
+		{pattern: "[unclosed", regexp: `\[unclosed`},
+	}
+	for _, test := range tests {
+		if got := patternRegexp(test.pattern); got != test.regexp {
+			t.Errorf("%q: converted to %q, want %q", test.pattern, got, test.regexp)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		line    string
+		ok      bool
+		fails   bool
+		negate  bool
+		dirOnly bool
+	}{
+		{line: ""},
+		{line: "   "},
+		{line: "# comment"},
+		{line: "*.log", ok: true},
+		{line: "*.log  ", ok: true},
+		{line: "!keep.log", ok: true, negate: true},
+		{line: "build/", ok: true, dirOnly: true},
+		{line: "!build/", ok: true, negate: true, dirOnly: true},
+		{line: `\#notes`, ok: true},
+		{line: "/", fails: true},
+		{line: "!", fails: true},
+	}
+	for _, test := range tests {
+		rule, ok, err := parseRule(test.line, "")
+		if (err != nil) != test.fails || ok != test.ok {
+			t.Errorf("%q: parsed %v, %v", test.line, ok, err)
+			continue
+		}
+		if ok && (rule.negate != test.negate || rule.dirOnly != test.dirOnly) {
+			t.Errorf("%q: negate %v and directories only %v", test.line, rule.negate, rule.dirOnly)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	tests := []struct {
+		lines    []string
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		// Patterns without a slash match at any depth
+		{lines: []string{"*.log"}, path: "app.log", excluded: true},
+		{lines: []string{"*.log"}, path: "logs/app.log", excluded: true},
+		{lines: []string{"*.log"}, path: "app.log.txt"},
+		{lines: []string{"?.txt"}, path: "a.txt", excluded: true},
+		{lines: []string{"?.txt"}, path: "ab.txt"},
+		// A slash anchors the pattern to the ignore file's directory
+		{lines: []string{"/build"}, path: "build", isDir: true, excluded: true},
+		{lines: []string{"/build"}, path: "src/build", isDir: true},
+		{lines: []string{"docs/*.md"}, path: "docs/a.md", excluded: true},
+		{lines: []string{"docs/*.md"}, path: "src/docs/a.md"},
+		{lines: []string{"docs/*.md"}, path: "docs/sub/a.md"},
+		// A trailing slash only matches directories
+		{lines: []string{"build/"}, path: "build", isDir: true, excluded: true},
+		{lines: []string{"build/"}, path: "src/build", isDir: true, excluded: true},
+		{lines: []string{"build/"}, path: "build"},
+		// "**" matches any amount of directories
+		{lines: []string{"**/temp"}, path: "temp", excluded: true},
+		{lines: []string{"**/temp"}, path: "a/b/temp", excluded: true},
+		{lines: []string{"logs/**"}, path: "logs/a/b", excluded: true},
+		{lines: []string{"logs/**"}, path: "logs", isDir: true},
+		{lines: []string{"a/**/b"}, path: "a/b", excluded: true},
+		{lines: []string{"a/**/b"}, path: "a/x/y/b", excluded: true},
+		{lines: []string{"a/**/b"}, path: "a/xb"},
+		// Character classes
+		{lines: []string{"[abc].txt"}, path: "b.txt", excluded: true},
+		{lines: []string{"[abc].txt"}, path: "d.txt"},
+		{lines: []string{"[!abc].txt"}, path: "d.txt", excluded: true},
+		{lines: []string{"[!abc].txt"}, path: "a.txt"},
+		{lines: []string{"file[0-9]"}, path: "file7", excluded: true},
+		{lines: []string{"file[0-9]"}, path: "filex"},
+		// The last matching pattern decides
+		{lines: []string{"*.log", "!keep.log"}, path: "app.log", excluded: true},
+		{lines: []string{"*.log", "!keep.log"}, path: "logs/keep.log"},
+		{lines: []string{"!keep.log", "*.log"}, path: "keep.log", excluded: true},
+		// Escaped characters are literal
+		{lines: []string{`\#notes`}, path: "#notes", excluded: true},
+		{lines: []string{`\!important`}, path: "!important", excluded: true},
+		{lines: []string{`\*`}, path: "a"},
+	}
+	for _, test := range tests {
+		rules := fileRules(t, test.lines...)
+		if excluded := rules.Excluded(test.path, test.isDir); excluded != test.excluded {
+			t.Errorf("%q with %q: excluded %v, want %v", test.path, test.lines, excluded, test.excluded)
+		}
+	}
+}
+
+func TestExcludedUnderBase(t *testing.T) {
+	tests := []struct {
+		line     string
+		path     string
+		excluded bool
+	}{
+		{line: "*.txt", path: "sub/a.txt", excluded: true},
+		{line: "*.txt", path: "sub/x/a.txt", excluded: true},
+		{line: "*.txt", path: "a.txt"},
+		{line: "/a.txt", path: "sub/a.txt", excluded: true},
+		{line: "/a.txt", path: "sub/x/a.txt"},
+		{line: "x/a.txt", path: "sub/x/a.txt", excluded: true},
+	}
+	for _, test := range tests {
+		rule, _, err := parseRule(test.line, "sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules := IgnoreRules{fileRules: []ignoreRule{rule}}
+		if excluded := rules.Excluded(test.path, false); excluded != test.excluded {
+			t.Errorf("%q with %q in sub: excluded %v, want %v", test.path, test.line, excluded, test.excluded)
+		}
+	}
+}
+
+func TestOptionsOverrideFiles(t *testing.T) {
+	rules := fileRules(t, "*.tmp", "!keep.log")
+	if err := rules.Exclude("*.log"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.Include("keep.tmp"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{path: "a.tmp", excluded: true},
+		{path: "keep.tmp"},
+		{path: "keep.log", excluded: true},
+		{path: "a.txt"},
+	}
+	for _, test := range tests {
+		if excluded := rules.Excluded(test.path, false); excluded != test.excluded {
+			t.Errorf("%q: excluded %v, want %v", test.path, excluded, test.excluded)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package Helper
+
+import (
+	"client/ClientErrors"
+	"strconv"
+	"strings"
+)
+
+const optionPrefix = "--"
+
+// Removes the "--name value" (or "--name=value") option from the command arguments.
+// Returns the option's value, whether the option has been given and the remaining arguments.
+func TakeOption(command_arguments []string, name string) (string, bool, []string, error) {
+	for i, argument := range command_arguments {
+		if argument == optionPrefix+name { // Value is the next argument
+			if i+1 >= len(command_arguments) {
+				return "", false, command_arguments, &ClientErrors.InvalidOptionError{Option: name, Value: ""}
+			}
+			remaining := append(append([]string(nil), command_arguments[:i]...), command_arguments[i+2:]...)
+			return command_arguments[i+1], true, remaining, nil
+		}
+		if value, found := strings.CutPrefix(argument, optionPrefix+name+"="); found {
+			remaining := append(append([]string(nil), command_arguments[:i]...), command_arguments[i+1:]...)
+			return value, true, remaining, nil
+		}
+	}
+	return "", false, command_arguments, nil
+}
+
+// Removes every "--name value" option from the command arguments, for options that can be repeated.
+// Returns the values in the order they have been given and the remaining arguments.
+func TakeAllOptions(command_arguments []string, name string) ([]string, []string, error) {
+	var values []string
+	for {
+		value, found, remaining, err := TakeOption(command_arguments, name)
+		if err != nil || !found {
+			return values, remaining, err
+		}
+		values = append(values, value)
+		command_arguments = remaining
+	}
+}
+
+// Removes the "--name value" option and converts its value to a number between min and max.
+// Returns defaultValue if the option hasn't been given.
+func TakeIntOption(command_arguments []string, name string, defaultValue int, min int, max int) (int, []string, error) {
+	value, found, remaining, err := TakeOption(command_arguments, name)
+	if err != nil || !found {
+		return defaultValue, remaining, err
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return defaultValue, remaining, &ClientErrors.InvalidOptionError{Option: name, Value: value}
+	}
+	return number, remaining, nil
+}
+
+// Removes the "--name" switch from the command arguments and returns whether it has been given
+func TakeFlag(command_arguments []string, name string) (bool, []string) {
+	for i, argument := range command_arguments {
+		if argument == optionPrefix+name {
+			return true, append(append([]string(nil), command_arguments[:i]...), command_arguments[i+1:]...)
+		}
+	}
+	return false, command_arguments
+}
@@ -0,0 +1,45 @@
+package Helper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Returns whether the file is a terminal, progress is only redrawn in place on a terminal
+func IsTerminal(file *os.File) bool {
+	fileInfo, err := file.Stat()
+	return err == nil && fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+// Returns the size with the largest decimal unit it fills, like the rates
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1e9:
+		return fmt.Sprintf("%.2f GB", float64(size)/1e9)
+	case size >= 1e6:
+		return fmt.Sprintf("%.2f MB", float64(size)/1e6)
+	case size >= 1e3:
+		return fmt.Sprintf("%.2f KB", float64(size)/1e3)
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// Renders the progress of a transfer: bytes done of the total, percent, rate and the time left.
+// The total is 0 if it's unknown and the rate is 0 until it has been measured, the parts they make are left out.
+func FormatProgress(done int64, total int64, rate float64) string {
+	parts := []string{FormatSize(done)}
+	if total > 0 {
+		done = min(done, total)
+		parts = []string{FormatSize(done) + "/" + FormatSize(total), fmt.Sprintf("%d%%", done*100/total)}
+	}
+	if rate >= 1 {
+		parts = append(parts, FormatRate(int64(rate)))
+		if total > done {
+			left := time.Duration(float64(total-done) / rate * float64(time.Second))
+			parts = append(parts, "ETA "+left.Round(time.Second).String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,173 @@
+package Helper
+
+import (
+	"client/ClientErrors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Unlimited  = 0 // Rate of a transfer that isn't limited
+	rateSuffix = "/s"
+	timeLayout = "15:04"
+)
+
+// Multipliers of the rate units, decimal units are powers of 1000 and binary units are powers of 1024
+var rateUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// Words that turn the limit off
+var unlimitedRates = map[string]bool{"": true, "0": true, "off": true, "none": true, "unlimited": true}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Converts a rate such as "2MB/s", "500KB/s" or "1.5MiB/s" to bytes per second.
+// An empty rate, "off" or 0 returns Unlimited.
+func ParseRate(text string) (int64, error) {
+	rate := strings.ToLower(strings.TrimSpace(text))
+	if unlimitedRates[rate] {
+		return Unlimited, nil
+	}
+	rate = strings.TrimSuffix(rate, rateSuffix)
+	unitIndex := strings.IndexFunc(rate, func(char rune) bool { return (char < '0' || char > '9') && char != '.' })
+	if unitIndex == -1 {
+		unitIndex = len(rate)
+	}
+	amount, err := strconv.ParseFloat(rate[:unitIndex], 64)
+	multiplier, ok := rateUnits[strings.TrimSpace(rate[unitIndex:])]
+	if err != nil || !ok || amount*multiplier < 1 {
+		return Unlimited, &ClientErrors.InvalidRateError{Rate: text}
+	}
+	return int64(amount * multiplier), nil
+}
+
+// Returns the rate with the largest decimal unit it fills, like the rates are typed
+func FormatRate(rate int64) string {
+	switch {
+	case rate <= Unlimited:
+		return "unlimited"
+	case rate >= 1e9:
+		return fmt.Sprintf("%.2f GB/s", float64(rate)/1e9)
+	case rate >= 1e6:
+		return fmt.Sprintf("%.2f MB/s", float64(rate)/1e6)
+	case rate >= 1e3:
+		return fmt.Sprintf("%.2f KB/s", float64(rate)/1e3)
+	}
+	return fmt.Sprintf("%d B/s", rate)
+}
+
+// A time of the week in which transfers are limited to their own rate.
+// A window whose end is before its start ends on the next day.
+type RateWindow struct {
+	Days []time.Weekday // Days the window starts on, every day if empty
+	From int            // Minutes since midnight
+	To   int
+	Rate int64
+}
+
+// Parses a limit schedule entry, days are written as "mon" to "sun" or in full and times as "09:00"
+func ParseRateWindow(days []string, from string, to string, rate string) (RateWindow, error) {
+	var window RateWindow
+	for _, day := range days {
+		weekday, ok := parseWeekday(day)
+		if !ok {
+			return RateWindow{}, &ClientErrors.InvalidScheduleError{Field: "day", Value: day}
+		}
+		window.Days = append(window.Days, weekday)
+	}
+
+	var err error
+	window.From, err = parseTimeOfDay(from)
+	if err != nil {
+		return RateWindow{}, err
+	}
+	window.To, err = parseTimeOfDay(to)
+	if err != nil {
+		return RateWindow{}, err
+	}
+	window.Rate, err = ParseRate(rate)
+	if err != nil {
+		return RateWindow{}, err
+	}
+	return window, nil
+}
+
+// Returns the day of a "mon" abbreviation or a full "monday" name
+func parseWeekday(day string) (time.Weekday, bool) {
+	day = strings.ToLower(day)
+	if weekday, ok := weekdays[day]; ok {
+		return weekday, true
+	}
+	for _, weekday := range weekdays {
+		if day == strings.ToLower(weekday.String()) {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+// Returns the minutes since midnight of a "15:04" time
+func parseTimeOfDay(text string) (int, error) {
+	moment, err := time.Parse(timeLayout, text)
+	if err != nil {
+		return 0, &ClientErrors.InvalidScheduleError{Field: "time", Value: text}
+	}
+	return moment.Hour()*60 + moment.Minute(), nil
+}
+
+// Returns whether the window starts on the given day
+func (window RateWindow) startsOn(day time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, weekday := range window.Days {
+		if weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether the given time is inside the window
+func (window RateWindow) Contains(moment time.Time) bool {
+	minute := moment.Hour()*60 + moment.Minute()
+	if window.From <= window.To {
+		return window.startsOn(moment.Weekday()) && minute >= window.From && minute < window.To
+	}
+	// The window goes past midnight, it's either the evening of its day or the morning after it
+	yesterday := (moment.Weekday() + 6) % 7
+	return (window.startsOn(moment.Weekday()) && minute >= window.From) || (window.startsOn(yesterday) && minute < window.To)
+}
+
+func (window RateWindow) String() string {
+	days := "every day"
+	if len(window.Days) > 0 {
+		names := make([]string, len(window.Days))
+		for i, day := range window.Days {
+			names[i] = day.String()[:3]
+		}
+		days = strings.Join(names, ",")
+	}
+	return fmt.Sprintf("%s %02d:%02d-%02d:%02d %s", days, window.From/60, window.From%60, window.To/60, window.To%60, FormatRate(window.Rate))
+}
@@ -0,0 +1,41 @@
+package Helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateWindowDays(t *testing.T) {
+	tests := []struct {
+		day     string
+		weekday time.Weekday
+		fails   bool
+	}{
+		{day: "mon", weekday: time.Monday},
+		{day: "Monday", weekday: time.Monday},
+		{day: "SAT", weekday: time.Saturday},
+		{day: "sunday", weekday: time.Sunday},
+		{day: "monkey", fails: true},
+		{day: "mo", fails: true},
+		{day: "thurs", fails: true},
+		{day: "", fails: true},
+		{day: "ẞ", fails: true},      // Lowercasing makes it shorter
+		{day: "Kelvin", fails: true}, // Starts with the Kelvin sign
+	}
+	for _, test := range tests {
+		window, err := ParseRateWindow([]string{test.day}, "09:00", "17:00", "1MB/s")
+		if test.fails {
+			if err == nil {
+				t.Errorf("%q: parsed as %v", test.day, window.Days)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.day, err)
+			continue
+		}
+		if len(window.Days) != 1 || window.Days[0] != test.weekday {
+			t.Errorf("%q: parsed as %v, want %v", test.day, window.Days, test.weekday)
+		}
+	}
+}
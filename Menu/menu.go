@@ -1,31 +1,63 @@
 package Menu
 
 import (
-	"client/ClientErrors"
+	"client/Config"
 	FileRequestsManager "client/FileRequests"
 	HandleInput "client/HandleInput"
+	"client/Helper"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 )
 
 const (
-	conn_addr = "clouddriveserver.duckdns.org:12345"
-	prompt    = ">> "
+	resumeFileName = "transfers.json"
+	syncFileName   = "sync.json"
 )
 
 type CLI struct {
-	socket net.Conn
-	prompt string
-	input  *HandleInput.UserInput
+	socket      net.Conn
+	serverAddr  string // Address of the command socket, used for reconnecting
+	prompt      string
+	input       *HandleInput.UserInput
+	username    string // Stored credentials one-shot commands sign in with
+	password    string
+	interactive bool // Commands are typed by the user, not piped in
 }
 
-func NewCLI() (*CLI, error) {
+func NewCLI(config Config.Config) (*CLI, error) {
+	// Both the command and the transmission sockets use the same TLS settings
+	Helper.SetTLSOptions(Helper.TLSOptions{CAFile: config.CAFile, PinSHA256: config.TLSPin, Insecure: config.TLSInsecure})
+	Helper.SetTransmissionAddr(config.TransmissionAddr)
+	Helper.SetTimeouts(config.DialTimeout.Duration, config.ResponseTimeout.Duration)
+	FileRequestsManager.SetDownloadDir(config.DownloadDir)
+	FileRequestsManager.SetStreams(config.Streams)
+	rate, err := Helper.ParseRate(config.Limit)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := config.RateWindows()
+	if err != nil {
+		return nil, err
+	}
+	FileRequestsManager.SetBandwidthLimit(rate, schedule)
+	if dir, err := Config.Dir(); err == nil { // Interrupted transfers and the sync state are kept next to the config file
+		FileRequestsManager.SetResumeFile(filepath.Join(dir, resumeFileName))
+		FileRequestsManager.SetSyncFile(filepath.Join(dir, syncFileName))
+	}
+
 	// Connect to the server
-	sock, err := net.Dial("tcp", conn_addr)
+	sock, err := Helper.Dial(config.ServerAddr)
 	if err != nil {
-		return nil, &ClientErrors.ServerConnectionError{Err: err}
+		return nil, err
 	}
-	return &CLI{socket: sock, prompt: prompt, input: HandleInput.NewUserInput()}, nil
+	cli := &CLI{socket: sock, serverAddr: config.ServerAddr, prompt: config.Prompt, input: HandleInput.NewUserInput(), username: config.Username, password: config.Password, interactive: Helper.IsTerminal(os.Stdin)}
+	FileRequestsManager.SetSessionOpener(cli.openSession) // Watch and sync jobs send their requests on sockets of their own
+	if cli.interactive {                                  // Piped input has no one to answer questions
+		FileRequestsManager.SetPrompt(cli.input.Ask) // Downloads ask the user about existing files
+	}
+	return cli, nil
 }
 
 func (cli *CLI) closeConnection() error {
@@ -39,29 +71,67 @@ func (cli *CLI) closeConnection() error {
 
 // Prints the program startup intro
 func (cli *CLI) PrintStartup() {
+	if !cli.interactive {
+		return
+	}
 	fmt.Println("CloudDrive v1.0 Command Line Interface!")
 	fmt.Println("Type \"help\" for available commands.")
 }
 
 // Print the prompt that gets output every command line
 func (cli *CLI) printPrompt() {
-	if FileRequestsManager.IsCurrentPathInitialized() { // If client has authenticated already
-		FileRequestsManager.PrintCurrentPath() // Print the current working directory path
+	if !cli.interactive { // Commands read from a pipe are run without prompts
+		return
 	}
-	fmt.Print(cli.prompt)
+	FileRequestsManager.PrintPrompt(cli.prompt) // After the current working directory path, once the client has authenticated
+
 }
 
-func (cli *CLI) readInput() {
+// Reads and handles one command. Returns an error if the commands have to stop:
+// the connection to the server couldn't be restored, or a piped command has failed after "set -e"
+func (cli *CLI) readInput() error {
 	cli.printPrompt()
-	fmt.Println(cli.input.HandleInput(cli.socket))
+	output, err := cli.input.HandleInput(cli.socket)
+	if err == nil {
+		if output != "" {
+			FileRequestsManager.PrintLine("%s\n", output)
+		}
+		return nil
+	}
+	if !cli.interactive && cli.input.StopsOnError() { // Piped commands stop like a script does
+		return err
+	}
+
+	FileRequestsManager.PrintLine("%s\n", err.Error())
+	if !Helper.IsConnectionLost(err) { // If the command failed but the connection is still alive
+		return nil
+	}
+	return cli.reconnect()
 }
 
-func (cli *CLI) Loop() {
+// Reads and runs commands until the input ends. Piped commands are run like a script: "set -e" stops them at the
+// first command that fails, and the transfers they have started are waited for.
+// Returns the exit code of the program, the interactive command line always succeeds.
+func (cli *CLI) Loop() int {
 	defer cli.closeConnection()
 	for {
-		cli.readInput()
+		err := cli.readInput()
+		if err != nil { // If the server couldn't be reached again, or a piped command has failed
+			FileRequestsManager.PrintLine("%s\n", err.Error())
+			if !cli.interactive {
+				return ExitCode(err)
+			}
+			return ExitSuccess
+		}
 		if cli.input.Scanner.Bytes() == nil { // If unexpected input given
-			break
+			if cli.interactive {
+				return ExitSuccess
+			}
+			err = waitForJobs() // Piped commands are done, let their transfers finish
+			if err != nil {
+				FileRequestsManager.PrintError(err)
+			}
+			return ExitCode(err)
 		}
 	}
 }
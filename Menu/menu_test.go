@@ -0,0 +1,130 @@
+package Menu
+
+import (
+	"bufio"
+	"bytes"
+	FileRequestsManager "client/FileRequests"
+	HandleInput "client/HandleInput"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Runs a command line and waits for the transfers it started
+func run(t *testing.T, cli *CLI, line string) {
+	t.Helper()
+	created := FileRequestsManager.JobsCreated()
+	if _, err := HandleInput.Execute(line, cli.socket); err != nil {
+		t.Fatalf("%s: %v", line, err)
+	}
+	if err := FileRequestsManager.WaitForJobs(created); err != nil {
+		t.Fatalf("%s: %v", line, err)
+	}
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	server, cli := startCLI(t)
+	server.MakeDir("bob", "Docs")
+	data := bytes.Repeat([]byte("cloud drive "), 10000)
+	local := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(local, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, cli, "signin bob secret")
+	run(t, cli, "uploadfile "+local+" Docs")
+	stored, err := server.ReadFile("bob", "Docs/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Fatalf("server stored %d bytes, uploaded %d", len(stored), len(data))
+	}
+
+	downloads := t.TempDir()
+	run(t, cli, "cd Docs")
+	run(t, cli, "downloadfile notes.txt "+downloads)
+	downloaded, err := os.ReadFile(filepath.Join(downloads, "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatalf("downloaded %d bytes, uploaded %d", len(downloaded), len(data))
+	}
+}
+
+func TestLegacyTextResponses(t *testing.T) {
+	server, cli := startCLI(t)
+	server.SetLegacyText(true)
+	server.MakeDir("bob", "Docs")
+	server.WriteFile("bob", "Docs/empty.txt", nil)
+	server.WriteFile("bob", "Docs/report.txt", []byte("quarterly report"))
+	local := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(local, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, cli, "signin bob secret")
+	run(t, cli, "cd Docs")
+	if FileRequestsManager.CurrentPath != "Root:\\Docs" {
+		t.Fatalf("current path is %q", FileRequestsManager.CurrentPath)
+	}
+	run(t, cli, "uploadfile "+local)
+	if stored, err := server.ReadFile("bob", "Docs/notes.txt"); err != nil || string(stored) != "notes" {
+		t.Fatalf("server stored %q: %v", stored, err)
+	}
+
+	downloads := t.TempDir()
+	run(t, cli, "downloadfile empty.txt "+downloads)
+	if info, err := os.Stat(filepath.Join(downloads, "empty.txt")); err != nil || info.Size() != 0 {
+		t.Fatalf("empty file wasn't created: %v", err)
+	}
+	run(t, cli, "downloadfile report.txt "+downloads)
+	if downloaded, err := os.ReadFile(filepath.Join(downloads, "report.txt")); err != nil || string(downloaded) != "quarterly report" {
+		t.Fatalf("downloaded %q: %v", downloaded, err)
+	}
+}
+
+func TestUploadWithoutChecksums(t *testing.T) {
+	server, cli := startCLI(t)
+	server.SetLegacyChecksums(true)
+	local := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(local, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, cli, "signin bob secret")
+	run(t, cli, "uploadfile "+local)
+	// Nothing tells the client when the server has stored an unverified upload
+	for wait := 0; wait < 100 && !server.Exists("bob", "notes.txt"); wait++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stored, err := server.ReadFile("bob", "notes.txt"); err != nil || string(stored) != "notes" {
+		t.Fatalf("server stored %q: %v", stored, err)
+	}
+}
+
+func TestPipedCommands(t *testing.T) {
+	tests := []struct {
+		input string
+		code  int
+		later bool // Whether the command after the failing one has run
+	}{
+		{input: "signin bob secret\nnewdir Docs\ncd Missing\nnewdir Later\n", code: ExitSuccess, later: true},
+		{input: "signin bob secret\nset -e\nnewdir Docs\ncd Missing\nnewdir Later\n", code: ExitFailure},
+		{input: "signin bob secret\nset -e\nnewdir Docs\nbogus\nnewdir Later\n", code: ExitUsage},
+	}
+	for _, test := range tests {
+		server, cli := startCLI(t)
+		cli.interactive = false
+		cli.input = &HandleInput.UserInput{Scanner: bufio.NewScanner(strings.NewReader(test.input))}
+		if code := cli.Loop(); code != test.code {
+			t.Errorf("%q: exit code %d, want %d", test.input, code, test.code)
+		}
+		if !server.Exists("bob", "Docs") || server.Exists("bob", "Later") != test.later {
+			t.Errorf("%q: the commands after the failing one have run: %t", test.input, server.Exists("bob", "Later"))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package Menu
+
+import (
+	"client/Authentication"
+	"client/ClientErrors"
+	FileRequestsManager "client/FileRequests"
+	HandleInput "client/HandleInput"
+	"client/Helper"
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// Exit codes of one-shot commands
+const (
+	ExitSuccess        = 0
+	ExitFailure        = 1 // The command has failed
+	ExitUsage          = 2 // Unknown command, wrong arguments or options
+	ExitConnection     = 3 // The server couldn't be reached or the connection has been lost
+	ExitAuthentication = 4 // No stored credentials, or the server has rejected them
+	ExitTransfer       = 5 // The command has succeeded but a transfer it has started has failed
+)
+
+// Commands that don't need to be signed in
+var noSignInCommands = map[string]bool{"help": true, "signup": true, "signin": true}
+
+// Runs a single command given in the program's arguments, for shell scripts and cron jobs.
+// Signs in with the stored credentials, runs the command and waits for the transfers it has started.
+// Returns the exit code of the program.
+func (cli *CLI) RunCommand(command []string) int {
+	defer cli.closeConnection()
+	err := cli.signIn(command)
+	if err == nil {
+		var output string
+		output, err = HandleInput.Run(command, cli.socket)
+		if output != "" {
+			FileRequestsManager.PrintLine("%s", output)
+		}
+	}
+	if err == nil {
+		err = waitForJobs()
+	}
+	if err != nil {
+		FileRequestsManager.PrintError(err)
+	}
+	return ExitCode(err)
+}
+
+// Runs the commands of the script file given with --script, then waits for the transfers it has started.
+// Signs in with the stored credentials if there are any, otherwise the script signs in by itself.
+// Returns the exit code of the program.
+func (cli *CLI) RunScript(path string) int {
+	defer cli.closeConnection()
+	var err error
+	if cli.username != "" {
+		err = cli.signInStored()
+	}
+	if err == nil {
+		err = HandleInput.Source(path, cli.socket)
+	}
+	if err == nil {
+		err = waitForJobs()
+	}
+	if err != nil {
+		FileRequestsManager.PrintError(err)
+	}
+	return ExitCode(err)
+}
+
+// Waits for the jobs the command or the script has started. A watch job only stops when it's cancelled, so it runs in
+// the foreground: an interrupt stops watching, and the program exits once the other jobs have finished.
+func waitForJobs() error {
+	if !FileRequestsManager.IsWatching() {
+		return FileRequestsManager.WaitForJobs(0)
+	}
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(interrupted, func() {
+		stop() // A second interrupt ends the program right away
+		FileRequestsManager.StopWatching()
+	})
+	err := FileRequestsManager.WaitForJobs(0)
+	FileRequestsManager.WaitForWatching()
+	return err
+}
+
+// Signs in with the stored credentials, unless the command doesn't need an account
+func (cli *CLI) signIn(command []string) error {
+	if len(command) == 0 || noSignInCommands[strings.ToLower(command[0])] {
+		return nil
+	}
+	if cli.username == "" {
+		return &ClientErrors.MissingCredentialsError{Command: command[0]}
+	}
+	return cli.signInStored()
+}
+
+func (cli *CLI) signInStored() error {
+	err := Authentication.HandleSignIn([]string{cli.username, cli.password}, &cli.socket)
+	if err != nil {
+		return err
+	}
+	FileRequestsManager.InitializeCurrentPath()
+	return nil
+}
+
+// Returns the exit code that describes the error
+func ExitCode(err error) int {
+	var (
+		commandErr     *ClientErrors.InvalidCommandError
+		argumentsErr   *ClientErrors.InvalidArgumentCountError
+		optionErr      *ClientErrors.InvalidOptionError
+		quoteErr       *ClientErrors.UnterminatedQuoteError
+		credentialsErr *ClientErrors.MissingCredentialsError
+		jobsErr        *ClientErrors.JobsFailedError
+		connectionErr  *ClientErrors.ServerConnectionError
+	)
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.As(err, &commandErr), errors.As(err, &argumentsErr), errors.As(err, &optionErr), errors.As(err, &quoteErr):
+		return ExitUsage
+	case errors.As(err, &connectionErr), Helper.IsConnectionLost(err):
+		return ExitConnection
+	case errors.As(err, &credentialsErr), errors.Is(err, ClientErrors.ErrAuthenticationFailed), errors.Is(err, ClientErrors.ErrNotAuthenticated):
+		return ExitAuthentication
+	case errors.As(err, &jobsErr):
+		return ExitTransfer
+	}
+	return ExitFailure
+}
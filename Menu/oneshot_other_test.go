@@ -0,0 +1,43 @@
+//go:build !windows
+
+package Menu
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOneShotWatchStopsOnInterrupt(t *testing.T) {
+	server, cli := startCLI(t)
+	cli.username, cli.password = "bob", "secret"
+	local := t.TempDir()
+	os.WriteFile(filepath.Join(local, "notes.txt"), []byte("notes"), 0o644)
+
+	code := make(chan int, 1)
+	go func() { code <- cli.RunCommand([]string{"watch", local, "Backup"}) }()
+	deadline := time.Now().Add(10 * time.Second)
+	for !server.Exists("bob", "Backup/notes.txt") {
+		if time.Now().After(deadline) {
+			t.Fatal("the folder hasn't been backed up")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	select {
+	case exit := <-code:
+		t.Fatalf("watch has exited with %d before it has been interrupted", exit)
+	case <-time.After(200 * time.Millisecond): // The command waits for the interrupt by now
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case exit := <-code:
+		if exit != ExitSuccess {
+			t.Fatalf("watch has exited with %d after the interrupt", exit)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch hasn't stopped after the interrupt")
+	}
+}
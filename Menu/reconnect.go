@@ -0,0 +1,98 @@
+package Menu
+
+import (
+	"client/Authentication"
+	"client/ClientErrors"
+	FileRequestsManager "client/FileRequests"
+	"client/Helper"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	reconnectAttempts   = 8
+	initialReconnectGap = 1 * time.Second
+	maxReconnectGap     = 30 * time.Second
+)
+
+// Redials the server after the command socket has died, with exponential backoff.
+// Restores the session on the new socket: signs in with the last credentials and returns to the current directory.
+func (cli *CLI) reconnect() error {
+	cli.closeConnection() // The old socket is dead, release it
+
+	FileRequestsManager.PrintLine("Connection to the server has been lost. Reconnecting...\n")
+	gap := initialReconnectGap
+	var err error
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		time.Sleep(gap)
+		err = cli.restoreSession()
+		if err == nil {
+			FileRequestsManager.PrintLine("Reconnected to the server. Please run the last command again.\n")
+			return nil
+		}
+		if !Helper.IsConnectionLost(err) && !isDialError(err) { // If the server is reachable but rejected the session
+			return cli.startSignedOut(err)
+		}
+
+		gap = min(gap*2, maxReconnectGap)
+		if attempt < reconnectAttempts {
+			FileRequestsManager.PrintLine("Reconnect attempt %d/%d failed, retrying in %s\n", attempt, reconnectAttempts, gap)
+		}
+	}
+	return err
+}
+
+// Returns whether the error happened while opening the connection
+func isDialError(err error) bool {
+	var connectionErr *ClientErrors.ServerConnectionError
+	return errors.As(err, &connectionErr)
+}
+
+// Opens a new command socket and restores the authentication and the working directory on it
+func (cli *CLI) restoreSession() error {
+	sock, err := cli.openSession()
+	if err != nil {
+		return err
+	}
+
+	err = FileRequestsManager.RestoreCurrentPath(&sock)
+	if Helper.IsConnectionLost(err) {
+		sock.Close()
+		return err
+	}
+	if err != nil { // If the working directory doesn't exist anymore
+		FileRequestsManager.InitializeCurrentPath()
+	}
+
+	cli.socket = sock
+	return nil
+}
+
+// Connects again without signing in, after the server has rejected the last credentials.
+// The user is back at the signed out prompt and can sign in again.
+func (cli *CLI) startSignedOut(reason error) error {
+	sock, err := Helper.Dial(cli.serverAddr)
+	if err != nil {
+		return err
+	}
+	Authentication.SignOut()
+	FileRequestsManager.ResetCurrentPath()
+	cli.socket = sock
+	FileRequestsManager.PrintLine("Reconnected to the server, but couldn't sign in again: %s\nPlease sign in.\n", reason.Error())
+	return nil
+}
+
+// Opens a new command socket signed in with the last credentials
+func (cli *CLI) openSession() (net.Conn, error) {
+	sock, err := Helper.Dial(cli.serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	err = Authentication.Reauthenticate(&sock)
+	if err != nil {
+		sock.Close()
+		return nil, err
+	}
+	return sock, nil
+}
@@ -0,0 +1,68 @@
+package Menu
+
+import (
+	FileRequestsManager "client/FileRequests"
+	HandleInput "client/HandleInput"
+	"client/TestServer"
+	"testing"
+)
+
+// Starts a test server with a user and a CLI connected to it
+func startCLI(t *testing.T) (*TestServer.Server, *CLI) {
+	server, err := TestServer.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	server.AddUser("bob", "secret")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // Resume and sync state stay out of the user's config directory
+
+	config := server.ClientConfig()
+	config.DownloadDir = t.TempDir()
+	cli, err := NewCLI(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.closeConnection() })
+	return server, cli
+}
+
+func TestReconnectRestoresSession(t *testing.T) {
+	server, cli := startCLI(t)
+	server.MakeDir("bob", "Docs")
+	for _, line := range []string{"signin bob secret", "cd Docs"} {
+		if _, err := HandleInput.Execute(line, cli.socket); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+
+	server.CloseConnections()
+	if err := cli.reconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if FileRequestsManager.CurrentPath != "Root:\\Docs" {
+		t.Fatalf("current path is %q after reconnecting", FileRequestsManager.CurrentPath)
+	}
+	if _, err := HandleInput.Execute("ls", cli.socket); err != nil {
+		t.Fatalf("ls after reconnecting: %v", err)
+	}
+}
+
+func TestReconnectRejectedSignsOut(t *testing.T) {
+	server, cli := startCLI(t)
+	if _, err := HandleInput.Execute("signin bob secret", cli.socket); err != nil {
+		t.Fatal(err)
+	}
+
+	server.AddUser("bob", "changed") // The saved credentials don't work anymore
+	server.CloseConnections()
+	if err := cli.reconnect(); err != nil {
+		t.Fatalf("reconnect ended the command line: %v", err)
+	}
+	if FileRequestsManager.IsCurrentPathInitialized() {
+		t.Fatalf("still signed in at %q", FileRequestsManager.CurrentPath)
+	}
+	if _, err := HandleInput.Execute("signin bob changed", cli.socket); err != nil {
+		t.Fatalf("signing in again: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package Requests
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	LargeFilesCapability = "large-files"  // The server handles sizes over 4 GiB
+	ChecksumCapability   = "checksums"    // The server answers FileChecksumRequest after every uploaded file
+	TreeCapability       = "tree"         // The server answers TreeRequest with every file and folder under a folder
+	MaxLegacySize        = math.MaxUint32 // Biggest size a server without LargeFilesCapability can hold
+)
+
+var (
+	capabilitiesLock sync.Mutex
+	capabilities     = make(map[string]bool) // Capabilities of the server the client has signed in to
+)
+
+// Sets the capabilities the server has announced when the client signed in
+func SetCapabilities(announced []string) {
+	capabilitiesLock.Lock()
+	defer capabilitiesLock.Unlock()
+	capabilities = make(map[string]bool, len(announced))
+	for _, capability := range announced {
+		capabilities[capability] = true
+	}
+}
+
+// Returns whether the server has announced the capability
+func Supports(capability string) bool {
+	capabilitiesLock.Lock()
+	defer capabilitiesLock.Unlock()
+	return capabilities[capability]
+}
+
+// Returns whether a file or directory of the given size can be sent to the server
+func SizeSupported(size uint64) bool {
+	return size <= MaxLegacySize || Supports(LargeFilesCapability)
+}
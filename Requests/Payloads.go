@@ -0,0 +1,222 @@
+package Requests
+
+import (
+	"client/ClientErrors"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	chunksSizePrefix       = "ChunksSize:"       // Text grant of servers that send the chunks size only
+	currentDirectoryPrefix = "CurrentDirectory:" // Text respone of servers that send the current directory only
+	maxChunksSize          = 64 * 1024 * 1024    // Biggest chunk size the client agrees to allocate
+	MaxStreams             = 16                  // Most transmission sockets a single file is transferred over
+)
+
+// Typed data of a server respone, validated after it has been decoded
+type Payload interface {
+	Validate() error
+}
+
+// Respone to change directory and garbage requests.
+// Older servers send it as a "CurrentDirectory:<path>" text message.
+type ChangeDirResponse struct {
+	CurrentDirectory string `json:"CurrentDirectory"`
+}
+
+// Respone to upload and download requests, the size of the chunks the file is transferred with.
+// Download grants also carry the file's size, the exact amount of bytes the server sends.
+// Offset is where the server continues a resumed transfer from, servers without resume support leave it 0.
+// A file split to several streams has a TransferID, every stream's socket starts with a TransferSegment.
+// Servers that keep metadata send the downloaded file's metadata with the grant.
+// Older servers send only the chunks size as a "ChunksSize:<size>" text message.
+type ChunkGrant struct {
+	ChunksSize int    `json:"ChunksSize"`
+	Size       uint64 `json:"Size,omitempty"`
+	Offset     uint64 `json:"Offset,omitempty"`
+	TransferID string `json:"TransferID,omitempty"`
+	Streams    int    `json:"Streams,omitempty"`
+	Metadata
+}
+
+// Modification time and permission bits of a file or a folder, zero if they haven't been sent
+type Metadata struct {
+	ModTime int64  `json:"ModTime,omitempty"` // Unix nanoseconds
+	Mode    uint32 `json:"Mode,omitempty"`    // Permission bits, including the executable bits
+}
+
+// Folder of a directory transfer, relative to the transferred directory.
+// Older servers and clients send only the folder's path as a text message.
+type FolderEntry struct {
+	Path string `json:"Path"`
+	Metadata
+}
+
+// First message of a stream of a segmented transfer, the range of the file the stream carries
+type TransferSegment struct {
+	TransferID string `json:"TransferID"`
+	Offset     uint64 `json:"Offset"`
+	Length     uint64 `json:"Length"`
+}
+
+// Respone to sign in and sign up requests. Servers that support optional features list them as capabilities,
+// older servers only respond with a text message.
+type SessionInfo struct {
+	Message      string   `json:"Message"`
+	Capabilities []string `json:"Capabilities"`
+}
+
+// SHA-256 digest of a transferred file.
+// Sent by the client after uploading a file, and by the server after the bytes of a downloaded file.
+type FileDigest struct {
+	Sha256 string `json:"Sha256"`
+}
+
+// Respone to tree requests, every file and folder under the requested folder
+type TreeListing struct {
+	Entries []TreeEntry `json:"Entries"`
+}
+
+// A file or a folder of a tree listing. Path is relative to the listed folder with "/" separators,
+// ModTime is when the content has last changed in Unix seconds. Files carry their size and SHA-256 digest.
+type TreeEntry struct {
+	Path    string `json:"Path"`
+	IsDir   bool   `json:"IsDir,omitempty"`
+	Size    uint64 `json:"Size,omitempty"`
+	ModTime int64  `json:"ModTime"`
+	Sha256  string `json:"Sha256,omitempty"`
+}
+
+func (respone ChangeDirResponse) Validate() error {
+	if respone.CurrentDirectory == "" {
+		return errors.New("current directory is missing")
+	}
+	return nil
+}
+
+func (grant ChunkGrant) Validate() error {
+	if grant.ChunksSize < 0 || grant.ChunksSize > maxChunksSize {
+		return &ClientErrors.ServerBadChunks{}
+	}
+	if grant.Size > 0 && grant.Offset > grant.Size {
+		return errors.New("resume offset is past the end of the file")
+	}
+	if grant.Streams < 0 || grant.Streams > MaxStreams {
+		return errors.New("invalid amount of streams")
+	}
+	return nil
+}
+
+// Checks the fields a download grant must have on top of Validate.
+// Empty files are granted no chunks. Servers before typed payloads only grant the chunks size, without the file's size.
+func (grant ChunkGrant) ValidateDownload() error {
+	if grant.ChunksSize == 0 && grant.Size > 0 {
+		return &ClientErrors.ServerBadChunks{}
+	}
+	return nil
+}
+
+func (respone *ChangeDirResponse) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' { // Only the current directory
+		var text string
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return err
+		}
+		directory, found := strings.CutPrefix(text, currentDirectoryPrefix)
+		if !found {
+			return errors.New("unexpected current directory '" + text + "'")
+		}
+		*respone = ChangeDirResponse{CurrentDirectory: directory}
+		return nil
+	}
+	type changeDirResponse ChangeDirResponse // Without the UnmarshalJSON method
+	return json.Unmarshal(data, (*changeDirResponse)(respone))
+}
+
+func (grant *ChunkGrant) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' { // Only the chunks size
+		var text string
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return err
+		}
+		chunksSize, found := strings.CutPrefix(text, chunksSizePrefix)
+		if !found {
+			return &ClientErrors.ServerBadChunks{}
+		}
+		*grant = ChunkGrant{}
+		grant.ChunksSize, err = strconv.Atoi(chunksSize)
+		if err != nil {
+			return &ClientErrors.ServerBadChunks{}
+		}
+		return nil
+	}
+	type chunkGrant ChunkGrant // Without the UnmarshalJSON method
+	return json.Unmarshal(data, (*chunkGrant)(grant))
+}
+
+func (session *SessionInfo) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' { // A server without capabilities
+		*session = SessionInfo{}
+		return json.Unmarshal(data, &session.Message)
+	}
+	type sessionInfo SessionInfo // Without the UnmarshalJSON method
+	return json.Unmarshal(data, (*sessionInfo)(session))
+}
+
+func (folder *FolderEntry) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' { // Only the folder's path
+		*folder = FolderEntry{}
+		return json.Unmarshal(data, &folder.Path)
+	}
+	type folderEntry FolderEntry // Without the UnmarshalJSON method
+	return json.Unmarshal(data, (*folderEntry)(folder))
+}
+
+func (folder FolderEntry) Validate() error {
+	if folder.Path == "" {
+		return errors.New("folder path is missing")
+	}
+	return ValidateRelativePath(filepath.FromSlash(folder.Path)) // Make sure the server can't create folders outside the downloaded folder
+}
+
+func (session SessionInfo) Validate() error {
+	return nil
+}
+
+func (digest FileDigest) Validate() error {
+	decoded, err := hex.DecodeString(digest.Sha256)
+	if err != nil || len(decoded) != sha256.Size {
+		return errors.New("invalid SHA-256 digest '" + digest.Sha256 + "'")
+	}
+	return nil
+}
+
+func (listing TreeListing) Validate() error {
+	for _, entry := range listing.Entries {
+		if entry.Path == "" || ValidateRelativePath(filepath.FromSlash(entry.Path)) != nil {
+			return errors.New("invalid path '" + entry.Path + "' in the tree listing")
+		}
+		if !entry.IsDir {
+			err := FileDigest{Sha256: entry.Sha256}.Validate()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Checks that a path sent by the server stays inside the local folder it is joined to
+func ValidateRelativePath(path string) error {
+	if path == "" || filepath.IsLocal(path) {
+		return nil
+	}
+	return errors.New("path '" + path + "' leaves the download folder")
+}
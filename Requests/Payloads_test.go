@@ -0,0 +1,90 @@
+package Requests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChunkGrantShapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		grant ChunkGrant
+		fails bool
+	}{
+		{name: "object", data: `{"ChunksSize":4096,"Size":10,"Offset":2}`, grant: ChunkGrant{ChunksSize: 4096, Size: 10, Offset: 2}},
+		{name: "object with metadata", data: `{"ChunksSize":1,"Size":1,"ModTime":5,"Mode":420}`, grant: ChunkGrant{ChunksSize: 1, Size: 1, Metadata: Metadata{ModTime: 5, Mode: 420}}},
+		{name: "text", data: `"ChunksSize:4096"`, grant: ChunkGrant{ChunksSize: 4096}},
+		{name: "empty file text", data: `"ChunksSize:0"`, grant: ChunkGrant{}},
+		{name: "text without prefix", data: `"4096"`, fails: true},
+		{name: "text with bad number", data: `"ChunksSize:many"`, fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var grant ChunkGrant
+			err := json.Unmarshal([]byte(test.data), &grant)
+			if test.fails {
+				if err == nil {
+					t.Fatalf("decoded %+v", grant)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if grant != test.grant {
+				t.Fatalf("decoded %+v, want %+v", grant, test.grant)
+			}
+		})
+	}
+}
+
+func TestChangeDirResponseShapes(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		directory string
+		fails     bool
+	}{
+		{name: "object", data: `{"CurrentDirectory":"Root:\\Docs"}`, directory: `Root:\Docs`},
+		{name: "text", data: `"CurrentDirectory:Root:\\Docs"`, directory: `Root:\Docs`},
+		{name: "text without prefix", data: `"Root:\\Docs"`, fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var respone ChangeDirResponse
+			err := json.Unmarshal([]byte(test.data), &respone)
+			if test.fails {
+				if err == nil {
+					t.Fatalf("decoded %+v", respone)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if respone.CurrentDirectory != test.directory {
+				t.Fatalf("current directory is %q, want %q", respone.CurrentDirectory, test.directory)
+			}
+		})
+	}
+}
+
+func TestValidateDownload(t *testing.T) {
+	tests := []struct {
+		name  string
+		grant ChunkGrant
+		fails bool
+	}{
+		{name: "file", grant: ChunkGrant{ChunksSize: 4096, Size: 10}},
+		{name: "empty file", grant: ChunkGrant{}},
+		{name: "legacy grant without size", grant: ChunkGrant{ChunksSize: 4096}},
+		{name: "missing chunks size", grant: ChunkGrant{Size: 10}, fails: true},
+	}
+	for _, test := range tests {
+		err := test.grant.ValidateDownload()
+		if (err != nil) != test.fails {
+			t.Errorf("%s: got error %v", test.name, err)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"client/ClientErrors"
 	"client/Helper"
 	"encoding/json"
-	"fmt"
 	"net"
 )
 
@@ -21,10 +20,13 @@ const (
 	ShowRequest            RequestType = 306
 	MoveRequest            RequestType = 307
 	GarbageRequest         RequestType = 308
+	TreeRequest            RequestType = 309
 	UploadFileRequest      RequestType = 401
 	DownloadFileRequest    RequestType = 402
 	UploadDirectoryRequest RequestType = 403
 	DownloadDirRequest     RequestType = 404
+	FileChecksumRequest    RequestType = 405
+	TransferSegmentRequest RequestType = 406
 	StopTransmission       RequestType = 501
 )
 
@@ -81,15 +83,34 @@ func SendRequestInfo(request_info RequestInfo, waitForRespone bool, socket net.C
 }
 
 // Handles the entire request-response cycle.
+// Returns the respone's text.
 func SendRequest(requestType RequestType, request_data []byte, socket *net.Conn) (string, error) {
+	response_info, err := sendAndCheck(requestType, request_data, socket)
+	if err != nil {
+		return "", err
+	}
+	return response_info.Text(), nil
+}
+
+// Handles the entire request-response cycle of a request with a typed respone.
+// Decodes the respone's data to the given payload.
+func SendTypedRequest(requestType RequestType, request_data []byte, socket *net.Conn, payload Payload) error {
+	response_info, err := sendAndCheck(requestType, request_data, socket)
+	if err != nil {
+		return err
+	}
+	return response_info.Decode(payload)
+}
+
+// Sends a request and receives its respone. Returns an error if the server has rejected the request
+func sendAndCheck(requestType RequestType, request_data []byte, socket *net.Conn) (ResponeInfo, error) {
 	request_info := BuildRequestInfo(requestType, request_data)
 	response_info, err := SendRequestInfo(request_info, true, *socket) // sends a request and receives a response
 	if err != nil {
-		return "", err
+		return ResponeInfo{}, err
 	}
-	if response_info.Type == ValidRespone { // If error caught in server side
-		return response_info.Respone, nil
-	} else {
-		return "", fmt.Errorf(response_info.Respone)
+	if response_info.Type != ValidRespone { // If error caught in server side
+		return ResponeInfo{}, response_info.Err()
 	}
+	return response_info, nil
 }
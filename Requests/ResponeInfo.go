@@ -1,28 +1,102 @@
-package Requests
-
-import (
-	"client/ClientErrors"
-	"encoding/json"
-)
-
-type ResponeType int
-
-const (
-	ErrorRespone ResponeType = 999
-	ValidRespone ResponeType = 200
-)
-
-type ResponeInfo struct {
-	Type    ResponeType `json:"Type"`
-	Respone string      `json:"Data"`
-}
-
-// Encode raw slice of bytes to ResponeInfo struct
-func GetResponseInfo(data []byte) (ResponeInfo, error) {
-	var response_info ResponeInfo
-	err := json.Unmarshal(data, &response_info)
-	if err != nil {
-		return ResponeInfo{}, &ClientErrors.JsonDecodeError{Err: err}
-	}
-	return response_info, nil
-}
+package Requests
+
+import (
+	"client/ClientErrors"
+	"encoding/json"
+	"fmt"
+)
+
+type ResponeType int
+
+const (
+	ErrorRespone ResponeType = 999
+	ValidRespone ResponeType = 200
+)
+
+// Error codes sent with ErrorRespone, explaining why the server rejected a request
+type ErrorCode int
+
+const (
+	NoErrorCode              ErrorCode = 0 // Older servers don't send error codes
+	NotFoundCode             ErrorCode = 1
+	AlreadyExistsCode        ErrorCode = 2
+	NotAuthenticatedCode     ErrorCode = 3
+	AuthenticationFailedCode ErrorCode = 4
+	QuotaExceededCode        ErrorCode = 5
+	PermissionDeniedCode     ErrorCode = 6
+	InvalidRequestCode       ErrorCode = 7
+	InternalErrorCode        ErrorCode = 8
+)
+
+type ResponeInfo struct {
+	Type    ResponeType     `json:"Type"`
+	Respone json.RawMessage `json:"Data"`           // Text message or a typed payload
+	Code    ErrorCode       `json:"Code,omitempty"` // Set with ErrorRespone
+}
+
+// Builds a ResponeInfo struct with the given payload encoded as its data
+func BuildResponeInfo(responeType ResponeType, payload any) (ResponeInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return ResponeInfo{}, &ClientErrors.JsonEncodeError{Err: err}
+	}
+	return ResponeInfo{Type: responeType, Respone: data}, nil
+}
+
+// Returns the typed error matching the respone's error code, with the respone's text as its message
+func (info ResponeInfo) Err() error {
+	message := info.Text()
+	switch info.Code {
+	case NotFoundCode:
+		return &ClientErrors.NotFoundError{Message: message}
+	case AlreadyExistsCode:
+		return &ClientErrors.AlreadyExistsError{Message: message}
+	case NotAuthenticatedCode:
+		return &ClientErrors.NotAuthenticatedError{Message: message}
+	case AuthenticationFailedCode:
+		return &ClientErrors.AuthenticationFailedError{Message: message}
+	case QuotaExceededCode:
+		return &ClientErrors.QuotaExceededError{Message: message}
+	case PermissionDeniedCode:
+		return &ClientErrors.PermissionDeniedError{Message: message}
+	case InvalidRequestCode:
+		return &ClientErrors.InvalidRequestError{Message: message}
+	case InternalErrorCode:
+		return &ClientErrors.InternalError{Message: message}
+	default:
+		return &ClientErrors.ServerError{Code: int(info.Code), Message: message}
+	}
+}
+
+// Returns the respone data as text
+func (info ResponeInfo) Text() string {
+	var text string
+	err := json.Unmarshal(info.Respone, &text)
+	if err != nil { // If data is not a json string, return it as is
+		return string(info.Respone)
+	}
+	return text
+}
+
+// Decodes the respone data to the given payload and validates it
+func (info ResponeInfo) Decode(payload Payload) error {
+	err := json.Unmarshal(info.Respone, payload)
+	if err != nil {
+		return &ClientErrors.UnexpectedResponeError{Expected: fmt.Sprintf("%T", payload), Respone: string(info.Respone), Err: err}
+	}
+	err = payload.Validate()
+	if err != nil {
+		return &ClientErrors.UnexpectedResponeError{Expected: fmt.Sprintf("%T", payload), Respone: string(info.Respone), Err: err}
+	}
+	return nil
+}
+
+// Encode raw slice of bytes to ResponeInfo struct
+func GetResponseInfo(data []byte) (ResponeInfo, error) {
+	var response_info ResponeInfo
+	err := json.Unmarshal(data, &response_info)
+	if err != nil {
+		return ResponeInfo{}, &ClientErrors.JsonDecodeError{Err: err}
+	}
+	return response_info, nil
+}
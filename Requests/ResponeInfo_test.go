@@ -0,0 +1,45 @@
+package Requests
+
+import (
+	"client/ClientErrors"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		code ErrorCode
+		kind error
+	}{
+		{NotFoundCode, ClientErrors.ErrNotFound},
+		{AlreadyExistsCode, ClientErrors.ErrAlreadyExists},
+		{NotAuthenticatedCode, ClientErrors.ErrNotAuthenticated},
+		{AuthenticationFailedCode, ClientErrors.ErrAuthenticationFailed},
+		{QuotaExceededCode, ClientErrors.ErrQuotaExceeded},
+		{PermissionDeniedCode, ClientErrors.ErrPermissionDenied},
+		{InvalidRequestCode, ClientErrors.ErrInvalidRequest},
+		{InternalErrorCode, ClientErrors.ErrInternal},
+		{NoErrorCode, nil},
+		{ErrorCode(99), nil},
+	}
+	for _, test := range tests {
+		info := ResponeInfo{Type: ErrorRespone, Code: test.code, Respone: json.RawMessage(`"it went wrong"`)}
+		err := info.Err()
+		if err.Error() == "" {
+			t.Errorf("code %d: empty message", test.code)
+		}
+		if !errors.Is(err, ClientErrors.ErrServer) {
+			t.Errorf("code %d: %T isn't a server error", test.code, err)
+		}
+		if test.kind != nil && !errors.Is(err, test.kind) {
+			t.Errorf("code %d: %T isn't %v", test.code, err, test.kind)
+		}
+		if test.kind == nil {
+			var serverErr *ClientErrors.ServerError
+			if !errors.As(err, &serverErr) || serverErr.Code != int(test.code) {
+				t.Errorf("code %d: got %T", test.code, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package TestServer
+
+import (
+	"client/Requests"
+	"errors"
+	"fmt"
+)
+
+// Error sent back to the client with its protocol error code
+type serverError struct {
+	code    Requests.ErrorCode
+	message string
+}
+
+func (error *serverError) Error() string {
+	return error.message
+}
+
+func newError(code Requests.ErrorCode, format string, args ...any) error {
+	return &serverError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Returns the protocol error code of an error, internal error if it has none
+func errorCode(err error) Requests.ErrorCode {
+	var coded *serverError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return Requests.InternalErrorCode
+}
@@ -0,0 +1,366 @@
+package TestServer
+
+import (
+	"client/Helper"
+	"client/Requests"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const chunkSize = 32 * 1024 // Chunk size granted for every transfer
+
+var (
+	errNoName          = newError(Requests.InvalidRequestCode, "a name must be given")
+	errNotFile         = newError(Requests.NotFoundCode, "the content is not a file")
+	errNotSignedIn     = newError(Requests.NotAuthenticatedCode, "please sign in first")
+	errWrongCredential = newError(Requests.AuthenticationFailedCode, "wrong username or password")
+)
+
+// File details sent by the client, same fields as the client's content struct
+type content struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Size    uint64 `json:"size"`
+	Offset  uint64 `json:"offset"`
+	Streams int    `json:"streams"`
+	Requests.Metadata
+}
+
+// A client connected to the command port
+type session struct {
+	server *Server
+	conn   net.Conn
+	user   string     // Signed in username, empty until the client authenticates
+	cwd    []string   // Current working directory parts
+	queued []transfer // Transfers granted by the request being handled, queued once the lock is released
+}
+
+// Handles the requests of one command socket until it's closed
+func (server *Server) serveControl(conn net.Conn) {
+	client := &session{server: server, conn: conn}
+	for {
+		data, err := Helper.ReciveData(&conn)
+		if err != nil {
+			return
+		}
+		var request Requests.RequestInfo
+		err = json.Unmarshal(data, &request)
+		if err != nil {
+			sendError(conn, newError(Requests.InvalidRequestCode, "bad request"))
+			continue
+		}
+
+		if injected, ok := server.nextInjection(request.Type); ok {
+			if injected.drop {
+				return
+			}
+			sendError(conn, newError(injected.code, "%s", injected.message))
+			continue
+		}
+
+		respone, err := client.handle(request)
+		if err != nil {
+			sendError(conn, err)
+			continue
+		}
+		client.respond(Requests.ValidRespone, respone)
+	}
+}
+
+// Sends a response on the session's socket
+func (client *session) respond(responeType Requests.ResponeType, payload any) error {
+	return sendRespone(client.conn, responeType, payload)
+}
+
+// Sends a response with the payload (text or a typed payload) as its data
+func sendRespone(conn net.Conn, responeType Requests.ResponeType, payload any) error {
+	info, err := Requests.BuildResponeInfo(responeType, payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return Helper.SendData(&conn, data)
+}
+
+// Sends an error response with the error's code
+func sendError(conn net.Conn, err error) error {
+	info, buildErr := Requests.BuildResponeInfo(Requests.ErrorRespone, err.Error())
+	if buildErr != nil {
+		return buildErr
+	}
+	info.Code = errorCode(err)
+	data, buildErr := json.Marshal(info)
+	if buildErr != nil {
+		return buildErr
+	}
+	return Helper.SendData(&conn, data)
+}
+
+// Returns the "Data" string field of a request
+func stringData(request Requests.RequestInfo) string {
+	var data struct {
+		Data string `json:"Data"`
+	}
+	json.Unmarshal(request.RequestData, &data)
+	return data.Data
+}
+
+// Options of a download request besides the file's path
+type downloadRequest struct {
+	Offset  uint64 `json:"Offset"`  // Bytes the client already has of a resumed download
+	Streams int    `json:"Streams"` // Most streams the client accepts to split the file to
+}
+
+// Returns the options of a download request
+func downloadOptions(request Requests.RequestInfo) downloadRequest {
+	var options downloadRequest
+	json.Unmarshal(request.RequestData, &options)
+	return options
+}
+
+// Returns the two paths of rename and move, from the Source and Destination fields,
+// or by splitting the "'first' 'second'" data of clients that don't send them
+func pathPair(request Requests.RequestInfo) (string, string, error) {
+	var paths struct {
+		Data        string `json:"Data"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	}
+	json.Unmarshal(request.RequestData, &paths)
+	if paths.Source != "" && paths.Destination != "" {
+		return paths.Source, paths.Destination, nil
+	}
+	parts := strings.Split(paths.Data, "'")
+	if len(parts) < 4 {
+		return "", "", newError(Requests.InvalidRequestCode, "two paths must be given")
+	}
+	return parts[1], parts[3], nil
+}
+
+// Handles one request and returns the payload of a valid response
+func (client *session) handle(request Requests.RequestInfo) (any, error) {
+	switch request.Type {
+	case Requests.SignupRequest, Requests.LoginRequest:
+		return client.authenticate(request)
+	case Requests.StopTransmission:
+		return nil, nil
+	}
+
+	if client.user == "" {
+		return nil, errNotSignedIn
+	}
+	server := client.server
+	server.mu.Lock()
+	respone, err := client.handleDrive(request)
+	queued := client.queued
+	client.queued = nil
+	server.mu.Unlock()
+
+	// Sending while holding the lock deadlocks once the queue is full, the transmission sockets take the lock too
+	for _, job := range queued {
+		server.pending <- job
+	}
+	return respone, err
+}
+
+// Handles a request on the signed in user's drive, must be called with the lock held
+func (client *session) handleDrive(request Requests.RequestInfo) (any, error) {
+	server := client.server
+	drive := server.drives[client.user]
+
+	switch request.Type {
+	case Requests.ChangeDirectoryRequest, Requests.GarbageRequest:
+		parts := []string{garbageName}
+		if request.Type == Requests.ChangeDirectoryRequest {
+			parts = resolve(client.cwd, stringData(request))
+		}
+		_, err := drive.folder(parts)
+		if err != nil {
+			return nil, err
+		}
+		client.cwd = parts
+		if server.legacyText {
+			return "CurrentDirectory:" + formatPath(parts), nil
+		}
+		return Requests.ChangeDirResponse{CurrentDirectory: formatPath(parts)}, nil
+
+	case Requests.CreateFileRequest, Requests.CreateFolderRequest:
+		parts := resolve(client.cwd, stringData(request))
+		if len(parts) == 0 {
+			return nil, errNoName
+		}
+		created := newFile(parts[len(parts)-1], nil)
+		if request.Type == Requests.CreateFolderRequest {
+			created = newFolder(parts[len(parts)-1])
+		}
+		return "Created", drive.add(parts[:len(parts)-1], created)
+
+	case Requests.DeleteContentRequest:
+		_, err := drive.remove(resolve(client.cwd, stringData(request)))
+		return "Deleted", err
+
+	case Requests.RenameRequest:
+		oldName, newName, err := pathPair(request)
+		if err != nil {
+			return nil, err
+		}
+		parts := resolve(client.cwd, oldName)
+		content, err := drive.remove(parts)
+		if err != nil {
+			return nil, err
+		}
+		renamed := *content
+		renamed.name = newName
+		err = drive.add(parts[:len(parts)-1], &renamed)
+		if err != nil {
+			drive.add(parts[:len(parts)-1], content) // Put the content back
+		}
+		return "Renamed", err
+
+	case Requests.MoveRequest:
+		source, destination, err := pathPair(request)
+		if err != nil {
+			return nil, err
+		}
+		sourceParts := resolve(client.cwd, source)
+		destinationParts := resolve(client.cwd, destination)
+		if _, err := drive.folder(destinationParts); err != nil {
+			return nil, err
+		}
+		content, err := drive.remove(sourceParts)
+		if err != nil {
+			return nil, err
+		}
+		err = drive.add(destinationParts, content)
+		if err != nil {
+			drive.add(sourceParts[:len(sourceParts)-1], content) // Put the content back
+		}
+		return "Moved", err
+
+	case Requests.ShowRequest:
+		folder, err := drive.folder(resolve(client.cwd, stringData(request)))
+		if err != nil {
+			return nil, err
+		}
+		return folder.listing(), nil
+
+	case Requests.TreeRequest:
+		folder, err := drive.folder(resolve(client.cwd, stringData(request)))
+		if err != nil {
+			return nil, err
+		}
+		return Requests.TreeListing{Entries: folder.tree("")}, nil
+
+	case Requests.UploadFileRequest, Requests.UploadDirectoryRequest:
+		var file content
+		err := json.Unmarshal(request.RequestData, &file)
+		if err != nil || file.Name == "" {
+			return nil, errNoName
+		}
+		folder := resolve(client.cwd, file.Path)
+		if _, err := drive.folder(folder); err != nil {
+			return nil, err
+		}
+		if quota, ok := server.quotas[client.user]; ok && drive.size()+int(file.Size) > quota {
+			return nil, newError(Requests.QuotaExceededCode, "not enough space left in the drive for '%s'", file.Name)
+		}
+		if request.Type == Requests.UploadDirectoryRequest {
+			err = drive.add(folder, newFolder(file.Name).withMetadata(file.Metadata))
+			if err != nil {
+				return nil, err
+			}
+			client.queued = append(client.queued, transfer{kind: uploadDirTransfer, user: client.user, folder: append(folder, file.Name)})
+			return "Uploading directory", nil
+		}
+		// Continue an interrupted upload from the bytes that have been received, at most from the client's offset
+		partial := server.partials[partialKey(client.user, folder, file.Name)]
+		offset := min(int(file.Offset), len(partial), int(file.Size))
+		if streams := min(file.Streams, Requests.MaxStreams); streams > 1 {
+			job := &segmentedTransfer{upload: true, user: client.user, folder: folder, name: file.Name, metadata: file.Metadata, data: make([]byte, file.Size), offset: offset}
+			copy(job.data, partial[:offset])
+			id := client.startSegmented(job, streams)
+			return Requests.ChunkGrant{ChunksSize: chunkSize, Offset: uint64(offset), TransferID: id, Streams: streams}, nil
+		}
+		client.queued = append(client.queued, transfer{kind: uploadFileTransfer, user: client.user, folder: folder, name: file.Name, size: int(file.Size), metadata: file.Metadata, offset: offset})
+		if server.legacyText {
+			return fmt.Sprintf("ChunksSize:%d", chunkSize), nil
+		}
+		return Requests.ChunkGrant{ChunksSize: chunkSize, Offset: uint64(offset)}, nil
+
+	case Requests.DownloadFileRequest:
+		parts := resolve(client.cwd, stringData(request))
+		file, err := drive.lookup(parts)
+		if err != nil {
+			return nil, err
+		}
+		if file.isDir {
+			return nil, errNotFile
+		}
+		if len(file.data) == 0 { // The client only creates empty files, without a transmission socket
+			if server.legacyText {
+				return "ChunksSize:0", nil
+			}
+			return Requests.ChunkGrant{ChunksSize: 0, Metadata: file.metadata()}, nil
+		}
+		if server.legacyText { // Servers before typed payloads neither resume nor split downloads, nor send the file's size
+			client.queued = append(client.queued, transfer{kind: downloadFileTransfer, user: client.user, folder: parts})
+			return fmt.Sprintf("ChunksSize:%d", chunkSize), nil
+		}
+		options := downloadOptions(request)
+		offset := min(int(options.Offset), len(file.data))
+		if streams := streamCount(options.Streams, len(file.data)-offset); streams > 1 {
+			job := &segmentedTransfer{data: append([]byte(nil), file.data...), offset: offset}
+			id := client.startSegmented(job, streams)
+			return Requests.ChunkGrant{ChunksSize: chunkSize, Size: uint64(len(file.data)), Offset: uint64(offset), TransferID: id, Streams: streams, Metadata: file.metadata()}, nil
+		}
+		client.queued = append(client.queued, transfer{kind: downloadFileTransfer, user: client.user, folder: parts, offset: offset})
+		return Requests.ChunkGrant{ChunksSize: chunkSize, Size: uint64(len(file.data)), Offset: uint64(offset), Metadata: file.metadata()}, nil
+
+	case Requests.DownloadDirRequest:
+		parts := resolve(client.cwd, stringData(request))
+		if _, err := drive.folder(parts); err != nil {
+			return nil, err
+		}
+		client.queued = append(client.queued, transfer{kind: downloadDirTransfer, user: client.user, folder: parts})
+		return "Downloading directory", nil
+	}
+	return nil, newError(Requests.InvalidRequestCode, "unknown request type %d", request.Type)
+}
+
+// Handles sign up and sign in requests
+func (client *session) authenticate(request Requests.RequestInfo) (any, error) {
+	var user struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	err := json.Unmarshal(request.RequestData, &user)
+	if err != nil || user.Username == "" {
+		return nil, errWrongCredential
+	}
+
+	server := client.server
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	password, exists := server.users[user.Username]
+	if request.Type == Requests.SignupRequest {
+		if exists {
+			return nil, newError(Requests.AlreadyExistsCode, "user '%s' already exists", user.Username)
+		}
+		server.users[user.Username] = user.Password
+		server.drives[user.Username] = newDrive()
+	} else if !exists || password != user.Password {
+		return nil, errWrongCredential
+	}
+
+	client.user = user.Username
+	client.cwd = nil
+	if server.legacySizes { // Servers before 64-bit sizes only respond with a message
+		return "Authenticated", nil
+	}
+	return Requests.SessionInfo{Message: "Authenticated", Capabilities: server.capabilities()}, nil
+}
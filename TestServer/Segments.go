@@ -0,0 +1,153 @@
+package TestServer
+
+import (
+	"client/Helper"
+	"client/Requests"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const minSegmentSize = 4 * 1024 * 1024 // Smallest range the server gives its own stream
+
+// A file transferred over several transmission sockets, every socket carries one range of it
+type segmentedTransfer struct {
+	upload    bool
+	user      string
+	folder    []string
+	name      string
+	metadata  Requests.Metadata // Sent with the upload
+	data      []byte            // The whole file, uploaded ranges are assembled in it
+	offset    int               // Where the transfer starts, the bytes before it have been received by an interrupted upload
+	done      map[int]int       // Offset to length of the uploaded ranges that have been verified
+	remaining int               // Streams that haven't been served yet
+}
+
+// Returns the amount of streams to split length bytes to, at most the requested amount
+func streamCount(requested int, length int) int {
+	return max(1, min(requested, Requests.MaxStreams, length/minSegmentSize))
+}
+
+// Registers a segmented transfer and queues a transmission socket for each of its streams.
+// Must be called with the lock held
+func (client *session) startSegmented(job *segmentedTransfer, streams int) string {
+	server := client.server
+	server.nextTransferID++
+	id := fmt.Sprintf("transfer-%d", server.nextTransferID)
+	job.done = make(map[int]int)
+	job.remaining = streams
+	server.segmented[id] = job
+	for i := 0; i < streams; i++ {
+		client.queued = append(client.queued, transfer{kind: segmentTransfer, id: id})
+	}
+	return id
+}
+
+// Serves one stream of a segmented transfer, the stream starts with the range it carries
+func (server *Server) serveSegment(conn net.Conn, id string) {
+	data, err := Helper.ReciveData(&conn)
+	if err != nil {
+		return
+	}
+	var request Requests.RequestInfo
+	var part Requests.TransferSegment
+	err = json.Unmarshal(data, &request)
+	if err == nil {
+		err = json.Unmarshal(request.RequestData, &part)
+	}
+	if err != nil || request.Type != Requests.TransferSegmentRequest || part.TransferID != id {
+		return
+	}
+
+	server.mu.Lock()
+	job, ok := server.segmented[id]
+	server.mu.Unlock()
+	start, end := int(part.Offset), int(part.Offset)+int(part.Length)
+	if !ok || start < job.offset || end > len(job.data) || start > end {
+		return
+	}
+	defer server.finishStream(id)
+
+	if job.upload {
+		server.receiveSegment(conn, job, start, end)
+		return
+	}
+	rangeData := job.data[start:end]
+	server.sendBytes(conn, rangeData, digest(rangeData))
+}
+
+// Reads one uploaded range and its digest. A verified range is added to the file,
+// the file is stored once every range has been verified.
+func (server *Server) receiveSegment(conn net.Conn, job *segmentedTransfer, start int, end int) error {
+	rangeData := make([]byte, end-start)
+	_, err := io.ReadFull(conn, rangeData)
+	if err != nil {
+		return err
+	}
+	if server.takeFaultIf(CorruptTransfer) && len(rangeData) > 0 {
+		rangeData[0] ^= 0xFF
+	}
+	if !server.checkDigest(conn, rangeData, job.name) {
+		return nil
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	copy(job.data[start:], rangeData)
+	job.done[start] = end - start
+
+	received := job.offset // The file has been received up to here without gaps
+	for length, ok := job.done[received]; ok; length, ok = job.done[received] {
+		received += length
+	}
+	key := partialKey(job.user, job.folder, job.name)
+	if received < len(job.data) {
+		server.partials[key] = job.data[:received] // Keep it for resuming
+		return nil
+	}
+	delete(server.partials, key)
+	return server.drives[job.user].put(job.folder, newFile(job.name, job.data).withMetadata(job.metadata))
+}
+
+// Forgets the segmented transfer once all of its streams have been served
+func (server *Server) finishStream(id string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	job, ok := server.segmented[id]
+	if !ok {
+		return
+	}
+	job.remaining--
+	if job.remaining == 0 {
+		delete(server.segmented, id)
+	}
+}
+
+// Transmission socket limited to a fixed rate, like a TCP stream over a high-latency link is limited by its window
+type throttledConn struct {
+	net.Conn
+	rate    int // Bytes per second
+	started time.Time
+	bytes   int
+}
+
+// Waits until the bytes transferred so far fit the rate
+func (conn *throttledConn) throttle(bytes int) {
+	conn.bytes += bytes
+	due := conn.started.Add(time.Duration(float64(conn.bytes) / float64(conn.rate) * float64(time.Second)))
+	time.Sleep(time.Until(due))
+}
+
+func (conn *throttledConn) Read(buffer []byte) (int, error) {
+	read, err := conn.Conn.Read(buffer)
+	conn.throttle(read)
+	return read, err
+}
+
+func (conn *throttledConn) Write(buffer []byte) (int, error) {
+	written, err := conn.Conn.Write(buffer)
+	conn.throttle(written)
+	return written, err
+}
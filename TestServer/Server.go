@@ -0,0 +1,418 @@
+// Package TestServer is an in-process fake CloudDrive server for tests.
+// It speaks the client's protocol on a loopback command port and a loopback transmission port,
+// keeps every user's drive in memory and can be scripted to fail or drop requests.
+package TestServer
+
+import (
+	"client/Config"
+	"client/Requests"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	loopbackAddr     = "127.0.0.1:0"
+	pendingTransfers = 64
+)
+
+// A scripted failure for the next request of some type
+type injection struct {
+	code    Requests.ErrorCode // Error code to respond with
+	message string             // Error message to respond with
+	drop    bool               // Close the connection instead of responding
+}
+
+type Server struct {
+	mu             sync.Mutex
+	users          map[string]string             // Username to password
+	drives         map[string]*node              // Username to the user's drive
+	quotas         map[string]int                // Username to the most bytes the user's drive may hold
+	partials       map[string][]byte             // Bytes received of interrupted uploads, by partialKey
+	segmented      map[string]*segmentedTransfer // Transfers split to several streams, by transfer ID
+	nextTransferID int
+	injected       map[Requests.RequestType][]injection
+	received       []Requests.RequestType // Every request type received on the command port, in order
+	streamed       []int                  // Bytes sent over every transmission socket that has carried a downloaded file or range
+	fault          TransferFault          // Fault to inject into the next file sent to a client
+	rate           int                    // Bytes per second of every transmission socket, unlimited when 0
+	legacySizes    bool                   // Behave like a server without 64-bit sizes
+	noChecksums    bool                   // Behave like a server that doesn't verify uploads
+	noTree         bool                   // Behave like a server that can't list folder trees
+	legacyText     bool                   // Send grants and directories as text messages, like servers before typed payloads
+
+	certPEM      []byte
+	control      net.Listener
+	transmission net.Listener
+	pending      chan transfer // Transfers waiting for their transmission socket, in request order
+	connections  map[net.Conn]struct{}
+	wg           sync.WaitGroup
+}
+
+// Starts a new server listening on loopback ports with a fresh self-signed certificate
+func Start() (*Server, error) {
+	certificate, certPEM, err := selfSignedCertificate()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{certificate}}
+
+	control, err := tls.Listen("tcp", loopbackAddr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	transmission, err := tls.Listen("tcp", loopbackAddr, tlsConfig)
+	if err != nil {
+		control.Close()
+		return nil, err
+	}
+
+	server := &Server{
+		users:        make(map[string]string),
+		drives:       make(map[string]*node),
+		quotas:       make(map[string]int),
+		partials:     make(map[string][]byte),
+		segmented:    make(map[string]*segmentedTransfer),
+		injected:     make(map[Requests.RequestType][]injection),
+		certPEM:      certPEM,
+		control:      control,
+		transmission: transmission,
+		pending:      make(chan transfer, pendingTransfers),
+		connections:  make(map[net.Conn]struct{}),
+	}
+	server.wg.Add(2)
+	go server.accept(control, server.serveControl)
+	go server.accept(transmission, server.serveTransmission)
+	return server, nil
+}
+
+// Stops listening and closes every open connection
+func (server *Server) Close() error {
+	err := server.control.Close()
+	server.transmission.Close()
+	server.CloseConnections()
+	server.wg.Wait()
+	return err
+}
+
+// Closes every open connection while the server keeps listening, like a server restart
+func (server *Server) CloseConnections() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for conn := range server.connections {
+		conn.Close()
+	}
+}
+
+// Address of the command port
+func (server *Server) Addr() string {
+	return server.control.Addr().String()
+}
+
+// Address of the transmission port
+func (server *Server) TransmissionAddr() string {
+	return server.transmission.Addr().String()
+}
+
+// PEM encoded certificate of the server, to be used as the client's CA bundle
+func (server *Server) CertificatePEM() []byte {
+	return server.certPEM
+}
+
+// Client settings pointing at this server. Certificate verification is skipped.
+func (server *Server) ClientConfig() Config.Config {
+	config := Config.Default()
+	config.ServerAddr = server.Addr()
+	config.TransmissionAddr = server.TransmissionAddr()
+	config.ResponseTimeout = Config.Duration{Duration: 2 * time.Second}
+	config.TLSInsecure = true
+	return config
+}
+
+// Registers an account with an empty drive
+func (server *Server) AddUser(username string, password string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.users[username] = password
+	server.drives[username] = newDrive()
+}
+
+// Stores a file in the user's drive, path is relative to the drive's root
+func (server *Server) WriteFile(username string, path string, data []byte) error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	parts := resolve(nil, path)
+	if len(parts) == 0 {
+		return errNoName
+	}
+	drive, err := server.drive(username)
+	if err != nil {
+		return err
+	}
+	return drive.put(parts[:len(parts)-1], newFile(parts[len(parts)-1], data))
+}
+
+// Creates a folder in the user's drive, path is relative to the drive's root
+func (server *Server) MakeDir(username string, path string) error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	parts := resolve(nil, path)
+	if len(parts) == 0 {
+		return errNoName
+	}
+	drive, err := server.drive(username)
+	if err != nil {
+		return err
+	}
+	return drive.add(parts[:len(parts)-1], newFolder(parts[len(parts)-1]))
+}
+
+// Returns a file's content from the user's drive, path is relative to the drive's root
+func (server *Server) ReadFile(username string, path string) ([]byte, error) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	drive, err := server.drive(username)
+	if err != nil {
+		return nil, err
+	}
+	file, err := drive.lookup(resolve(nil, path))
+	if err != nil {
+		return nil, err
+	}
+	if file.isDir {
+		return nil, errNotFile
+	}
+	return append([]byte(nil), file.data...), nil
+}
+
+// Returns whether a file or a folder exists in the user's drive
+func (server *Server) Exists(username string, path string) bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	drive, err := server.drive(username)
+	if err != nil {
+		return false
+	}
+	_, err = drive.lookup(resolve(nil, path))
+	return err == nil
+}
+
+// Key of an interrupted upload in the partials map
+func partialKey(user string, folder []string, name string) string {
+	return user + ":" + formatPath(append(append([]string(nil), folder...), name))
+}
+
+// Returns the user's drive. Must be called with the lock held
+func (server *Server) drive(username string) (*node, error) {
+	drive, ok := server.drives[username]
+	if !ok {
+		return nil, newError(Requests.NotFoundCode, "user '%s' does not exist", username)
+	}
+	return drive, nil
+}
+
+// Makes the next request of the given type fail with the given error code and message
+func (server *Server) FailNext(requestType Requests.RequestType, code Requests.ErrorCode, message string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.injected[requestType] = append(server.injected[requestType], injection{code: code, message: message})
+}
+
+// Injects the fault into the next file the server sends.
+// TruncateTransfer and CorruptTransfer also apply to the next file the server receives.
+func (server *Server) FaultNextTransfer(fault TransferFault) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.fault = fault
+}
+
+// Returns the fault for the file being sent and clears it
+func (server *Server) takeFault() TransferFault {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	fault := server.fault
+	server.fault = NoFault
+	return fault
+}
+
+// Returns whether the fault is set for the next file, and clears it if it is
+func (server *Server) takeFaultIf(fault TransferFault) bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.fault != fault {
+		return false
+	}
+	server.fault = NoFault
+	return true
+}
+
+// Limits every transmission socket to the given bytes per second, 0 removes the limit.
+// Simulates a high-latency link, where more streams get more throughput.
+func (server *Server) SetStreamRate(bytesPerSecond int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.rate = bytesPerSecond
+}
+
+// Makes the server behave like a server before 64-bit sizes, it doesn't announce LargeFilesCapability
+func (server *Server) SetLegacySizes(legacy bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.legacySizes = legacy
+}
+
+// Makes the server behave like a server before upload checksums, it doesn't announce ChecksumCapability
+func (server *Server) SetLegacyChecksums(legacy bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.noChecksums = legacy
+}
+
+// Makes the server behave like a server before tree listings, it doesn't announce TreeCapability
+func (server *Server) SetLegacyTree(legacy bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.noTree = legacy
+}
+
+// Returns the capabilities the server announces to clients that sign in, must be called with the lock held
+func (server *Server) capabilities() []string {
+	var announced []string
+	if server.legacySizes { // Servers before 64-bit sizes don't announce anything
+		return announced
+	}
+	announced = append(announced, Requests.LargeFilesCapability)
+	if !server.noChecksums {
+		announced = append(announced, Requests.ChecksumCapability)
+	}
+	if !server.noTree {
+		announced = append(announced, Requests.TreeCapability)
+	}
+	return announced
+}
+
+// Returns whether the server announces the capability
+func (server *Server) announces(capability string) bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return slices.Contains(server.capabilities(), capability)
+}
+
+// Makes the server send chunk grants and current directories as text messages, like servers before typed payloads
+func (server *Server) SetLegacyText(legacy bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.legacyText = legacy
+}
+
+// Limits the total size of the files in the user's drive, uploads beyond it fail with QuotaExceededCode
+func (server *Server) SetQuota(username string, bytes int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.quotas[username] = bytes
+}
+
+// Makes the server close the connection when the next request of the given type arrives
+func (server *Server) DropNext(requestType Requests.RequestType) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.injected[requestType] = append(server.injected[requestType], injection{drop: true})
+}
+
+// Returns the request types received on the command port, in order
+func (server *Server) Received() []Requests.RequestType {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]Requests.RequestType(nil), server.received...)
+}
+
+// Returns the bytes sent over every transmission socket that has carried a downloaded file or range, in the order they have finished
+func (server *Server) Streamed() []int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]int(nil), server.streamed...)
+}
+
+// Records the bytes that a transmission socket has sent
+func (server *Server) addStreamed(bytes int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.streamed = append(server.streamed, bytes)
+}
+
+// Returns the scripted failure for the request, if there is one
+func (server *Server) nextInjection(requestType Requests.RequestType) (injection, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.received = append(server.received, requestType)
+	queue := server.injected[requestType]
+	if len(queue) == 0 {
+		return injection{}, false
+	}
+	server.injected[requestType] = queue[1:]
+	return queue[0], true
+}
+
+// Accepts connections until the listener is closed
+func (server *Server) accept(listener net.Listener, serve func(net.Conn)) {
+	defer server.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		server.mu.Lock()
+		server.connections[conn] = struct{}{}
+		server.mu.Unlock()
+
+		server.wg.Add(1)
+		go func() {
+			defer server.wg.Done()
+			defer server.forget(conn)
+			serve(conn)
+		}()
+	}
+}
+
+// Closes a connection and stops tracking it
+func (server *Server) forget(conn net.Conn) {
+	conn.Close()
+	server.mu.Lock()
+	delete(server.connections, conn)
+	server.mu.Unlock()
+}
+
+// Creates a self-signed certificate for 127.0.0.1 and localhost
+func selfSignedCertificate() (tls.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "CloudDrive test server"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	certificate := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return certificate, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
@@ -0,0 +1,270 @@
+package TestServer
+
+import (
+	"client/Helper"
+	"client/Requests"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"path"
+	"time"
+)
+
+type transferKind int
+
+const (
+	uploadFileTransfer transferKind = iota
+	downloadFileTransfer
+	uploadDirTransfer
+	downloadDirTransfer
+	segmentTransfer // One stream of a segmented transfer
+
+	transmissionWait = 5 * time.Second // How long a transmission socket waits for its transfer request
+)
+
+// Faults the server can inject into the next file it sends
+type TransferFault int
+
+const (
+	NoFault          TransferFault = iota
+	TruncateTransfer               // Close the socket half way through the file, the received half is kept for resuming
+	ExtraBytes                     // Send garbage bytes after the file's bytes
+	CorruptTransfer                // Flip a byte of the file, the digest is still of the original file
+)
+
+// A transfer that has been accepted on the command port and waits for its transmission socket
+type transfer struct {
+	kind     transferKind
+	user     string
+	folder   []string          // Destination folder of uploads, source path of downloads
+	name     string            // Name of an uploaded file
+	size     int               // Size of an uploaded file
+	metadata Requests.Metadata // Sent with an uploaded file
+	offset   int               // Where a resumed transfer continues from
+	id       string            // ID of a segmented transfer
+}
+
+// Runs the next pending transfer over the transmission socket
+func (server *Server) serveTransmission(conn net.Conn) {
+	server.mu.Lock()
+	rate := server.rate
+	server.mu.Unlock()
+	if rate > 0 {
+		conn = &throttledConn{Conn: conn, rate: rate, started: time.Now()}
+	}
+
+	var job transfer
+	select {
+	case job = <-server.pending:
+	case <-time.After(transmissionWait):
+		return
+	}
+
+	switch job.kind {
+	case uploadFileTransfer:
+		server.receiveFile(conn, job.user, job.folder, job.name, job.size, job.metadata, job.offset)
+	case downloadFileTransfer:
+		file, err := server.snapshot(job.user, job.folder)
+		if err == nil {
+			server.sendFile(conn, file.data, job.offset)
+		}
+	case uploadDirTransfer:
+		server.receiveDirectory(conn, job)
+	case segmentTransfer:
+		server.serveSegment(conn, job.id)
+	case downloadDirTransfer:
+		folder, err := server.snapshot(job.user, job.folder)
+		if err == nil {
+			sendRespone(conn, Requests.ResponeType(Requests.CreateFolderRequest), Requests.FolderEntry{Path: ".", Metadata: folder.metadata()}) // The downloaded folder itself
+			server.sendDirectory(conn, folder, "")
+			sendRespone(conn, Requests.ResponeType(Requests.StopTransmission), "")
+		}
+	}
+}
+
+// Returns a copy of the content in the given path parts of the user's drive
+func (server *Server) snapshot(user string, parts []string) (*node, error) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	content, err := server.drives[user].lookup(parts)
+	if err != nil {
+		return nil, err
+	}
+	return content.clone(), nil
+}
+
+// Reads the file's bytes from offset to size from the socket, then the client's digest of the file.
+// The file is stored only if the digest matches the received bytes.
+// If the socket is closed half way, the received bytes are kept so the upload can be resumed.
+func (server *Server) receiveFile(conn net.Conn, user string, folder []string, name string, size int, metadata Requests.Metadata, offset int) error {
+	key := partialKey(user, folder, name)
+	server.mu.Lock()
+	data := make([]byte, size)
+	copy(data, server.partials[key][:offset])
+	delete(server.partials, key)
+	server.mu.Unlock()
+
+	end := size
+	if server.takeFaultIf(TruncateTransfer) {
+		end = offset + (size-offset)/2
+	}
+	received, err := io.ReadFull(conn, data[offset:end])
+	if err != nil || end < size {
+		server.mu.Lock()
+		server.partials[key] = data[:offset+received]
+		server.mu.Unlock()
+		if err == nil {
+			err = conn.Close()
+		}
+		return err
+	}
+	if server.takeFaultIf(CorruptTransfer) && size > 0 {
+		data[0] ^= 0xFF
+	}
+
+	if !server.checkDigest(conn, data, name) {
+		return nil
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.drives[user].put(folder, newFile(name, data).withMetadata(metadata))
+}
+
+// Reads the client's digest of the received bytes and responds with the digest of the bytes that have arrived.
+// Returns whether the digests match, always true when the server doesn't verify uploads.
+func (server *Server) checkDigest(conn net.Conn, data []byte, name string) bool {
+	if !server.announces(Requests.ChecksumCapability) {
+		return true
+	}
+	message, err := Helper.ReciveData(&conn)
+	if err != nil {
+		return false
+	}
+	var request Requests.RequestInfo
+	var sent Requests.FileDigest
+	err = json.Unmarshal(message, &request)
+	if err == nil {
+		err = json.Unmarshal(request.RequestData, &sent)
+	}
+	if err != nil || request.Type != Requests.FileChecksumRequest {
+		sendError(conn, newError(Requests.InvalidRequestCode, "expected the digest of '%s'", name))
+		return false
+	}
+	sum := digest(data)
+	err = sendRespone(conn, Requests.ValidRespone, sum)
+	return err == nil && sum.Sha256 == sent.Sha256
+}
+
+// Returns the SHA-256 digest of the data
+func digest(data []byte) Requests.FileDigest {
+	sum := sha256.Sum256(data)
+	return Requests.FileDigest{Sha256: hex.EncodeToString(sum[:])}
+}
+
+// Sends the file's bytes from offset in chunks, followed by the stop transmission message with the file's digest
+func (server *Server) sendFile(conn net.Conn, data []byte, offset int) error {
+	return server.sendBytes(conn, data[offset:], digest(data))
+}
+
+// Sends the bytes in chunks, followed by the stop transmission message with the given digest
+func (server *Server) sendBytes(conn net.Conn, data []byte, sum Requests.FileDigest) error {
+	switch server.takeFault() {
+	case TruncateTransfer:
+		conn.Write(data[:len(data)/2])
+		return conn.Close()
+	case ExtraBytes:
+		data = append(append([]byte(nil), data...), "unexpected bytes"...)
+	case CorruptTransfer:
+		data = append([]byte(nil), data...)
+		data[0] ^= 0xFF
+	}
+
+	for start := 0; start < len(data); start += chunkSize {
+		_, err := conn.Write(data[start:min(start+chunkSize, len(data))])
+		if err != nil {
+			return err
+		}
+	}
+	server.addStreamed(len(data)) // Before the client can finish the transfer
+	return sendRespone(conn, Requests.ResponeType(Requests.StopTransmission), sum)
+}
+
+// Handles the requests of an upload directory transmission until the client stops it
+func (server *Server) receiveDirectory(conn net.Conn, job transfer) {
+	for {
+		data, err := Helper.ReciveData(&conn)
+		if err != nil {
+			return
+		}
+		var request Requests.RequestInfo
+		err = json.Unmarshal(data, &request)
+		if err != nil {
+			return
+		}
+
+		switch request.Type {
+		case Requests.UploadFileRequest:
+			var file content
+			json.Unmarshal(request.RequestData, &file)
+			sendRespone(conn, Requests.ValidRespone, Requests.ChunkGrant{ChunksSize: chunkSize})
+			err = server.receiveFile(conn, job.user, resolve(job.folder, file.Path), file.Name, int(file.Size), file.Metadata, 0)
+			if err != nil {
+				return
+			}
+		case Requests.CreateFolderRequest:
+			var folder struct {
+				Data string `json:"Data"`
+				Requests.Metadata
+			}
+			json.Unmarshal(request.RequestData, &folder)
+			parts := resolve(job.folder, folder.Data)
+			server.mu.Lock()
+			err = server.drives[job.user].add(parts[:len(parts)-1], newFolder(parts[len(parts)-1]).withMetadata(folder.Metadata))
+			server.mu.Unlock()
+			if err != nil {
+				sendError(conn, err)
+				continue
+			}
+			sendRespone(conn, Requests.ValidRespone, "Created")
+		case Requests.StopTransmission:
+			return
+		}
+	}
+}
+
+// Sends every folder and file under the folder, relative paths start at the downloaded folder
+func (server *Server) sendDirectory(conn net.Conn, folder *node, relativePath string) error {
+	for _, child := range folder.sorted() {
+		childPath := path.Join(relativePath, child.name)
+		if child.isDir {
+			err := sendRespone(conn, Requests.ResponeType(Requests.CreateFolderRequest), Requests.FolderEntry{Path: childPath, Metadata: child.metadata()})
+			if err != nil {
+				return err
+			}
+			err = server.sendDirectory(conn, child, childPath)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := sendRespone(conn, Requests.ResponeType(Requests.DownloadFileRequest), content{Name: child.name, Path: relativePath, Size: uint64(len(child.data)), Metadata: child.metadata()})
+		if err != nil {
+			return err
+		}
+		err = sendRespone(conn, Requests.ValidRespone, Requests.ChunkGrant{ChunksSize: chunkSize})
+		if err != nil {
+			return err
+		}
+		if len(child.data) > 0 { // The client only creates empty files
+			err = server.sendFile(conn, child.data, 0)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
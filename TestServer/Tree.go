@@ -0,0 +1,222 @@
+package TestServer
+
+import (
+	"client/Requests"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	rootName    = "Root:"
+	garbageName = "Garbage"
+)
+
+// A file or a folder in the in-memory drive
+type node struct {
+	name     string
+	isDir    bool
+	data     []byte
+	children map[string]*node
+	modTime  time.Time // When the content has been created or replaced, or the time the client has uploaded it with
+	mode     uint32    // Permission bits the client has uploaded it with, 0 if it hasn't sent them
+}
+
+func newFolder(name string) *node {
+	return &node{name: name, isDir: true, children: make(map[string]*node), modTime: time.Now()}
+}
+
+func newFile(name string, data []byte) *node {
+	return &node{name: name, data: data, modTime: time.Now()}
+}
+
+// Keeps the metadata the client has uploaded the content with
+func (content *node) withMetadata(metadata Requests.Metadata) *node {
+	if metadata.ModTime != 0 {
+		content.modTime = time.Unix(0, metadata.ModTime)
+	}
+	content.mode = metadata.Mode
+	return content
+}
+
+// Returns the metadata sent with the content when it's downloaded
+func (content *node) metadata() Requests.Metadata {
+	return Requests.Metadata{ModTime: content.modTime.UnixNano(), Mode: content.mode}
+}
+
+// Creates the drive of a new user, with the Garbage folder every account starts with
+func newDrive() *node {
+	root := newFolder(rootName)
+	root.children[garbageName] = newFolder(garbageName)
+	return root
+}
+
+// Splits a cloud path to its parts, relative to cwd unless it starts with "Root:".
+// Both \ and / separate the parts, ".." goes one folder up.
+func resolve(cwd []string, path string) []string {
+	path = strings.Trim(strings.TrimSpace(path), "'")
+	var parts []string
+	if strings.HasPrefix(path, rootName) { // If path is absolute
+		path = strings.TrimPrefix(path, rootName)
+	} else {
+		parts = append(parts, cwd...)
+	}
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '\\' || r == '/' }) {
+		switch part {
+		case ".":
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// Formats the path parts the way the server prints the current directory
+func formatPath(parts []string) string {
+	return rootName + "\\" + strings.Join(parts, "\\")
+}
+
+// Returns the node in the given path parts
+func (root *node) lookup(parts []string) (*node, error) {
+	current := root
+	for _, part := range parts {
+		if !current.isDir {
+			return nil, newError(Requests.NotFoundCode, "'%s' is not a folder", current.name)
+		}
+		child, ok := current.children[part]
+		if !ok {
+			return nil, newError(Requests.NotFoundCode, "'%s' does not exist", part)
+		}
+		current = child
+	}
+	return current, nil
+}
+
+// Returns the folder in the given path parts
+func (root *node) folder(parts []string) (*node, error) {
+	folder, err := root.lookup(parts)
+	if err != nil {
+		return nil, err
+	}
+	if !folder.isDir {
+		return nil, newError(Requests.NotFoundCode, "'%s' is not a folder", folder.name)
+	}
+	return folder, nil
+}
+
+// Adds a new content to the folder in the given path parts
+func (root *node) add(parts []string, content *node) error {
+	folder, err := root.folder(parts)
+	if err != nil {
+		return err
+	}
+	if _, ok := folder.children[content.name]; ok {
+		return newError(Requests.AlreadyExistsCode, "'%s' already exists", content.name)
+	}
+	folder.children[content.name] = content
+	return nil
+}
+
+// Adds or replaces a file in the folder in the given path parts
+func (root *node) put(parts []string, file *node) error {
+	folder, err := root.folder(parts)
+	if err != nil {
+		return err
+	}
+	if existing, ok := folder.children[file.name]; ok && existing.isDir {
+		return newError(Requests.AlreadyExistsCode, "'%s' is a folder", file.name)
+	}
+	folder.children[file.name] = file
+	return nil
+}
+
+// Removes the content in the given path parts from its folder and returns it
+func (root *node) remove(parts []string) (*node, error) {
+	if len(parts) == 0 {
+		return nil, newError(Requests.PermissionDeniedCode, "the root folder can't be changed")
+	}
+	folder, err := root.folder(parts[:len(parts)-1])
+	if err != nil {
+		return nil, err
+	}
+	content, ok := folder.children[parts[len(parts)-1]]
+	if !ok {
+		return nil, newError(Requests.NotFoundCode, "'%s' does not exist", parts[len(parts)-1])
+	}
+	delete(folder.children, content.name)
+	return content, nil
+}
+
+// Returns a deep copy of the node, safe to read without holding the server's lock
+func (content *node) clone() *node {
+	copied := &node{name: content.name, isDir: content.isDir, data: append([]byte(nil), content.data...), modTime: content.modTime, mode: content.mode}
+	if content.isDir {
+		copied.children = make(map[string]*node, len(content.children))
+		for name, child := range content.children {
+			copied.children[name] = child.clone()
+		}
+	}
+	return copied
+}
+
+// Returns the total size of the files under the node
+func (content *node) size() int {
+	if !content.isDir {
+		return len(content.data)
+	}
+	total := 0
+	for _, child := range content.children {
+		total += child.size()
+	}
+	return total
+}
+
+// Returns the folder's children sorted by name
+func (content *node) sorted() []*node {
+	children := make([]*node, 0, len(content.children))
+	for _, child := range content.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+// Lists the folder's contents the way the ls command prints them
+func (content *node) listing() string {
+	var builder strings.Builder
+	for _, child := range content.sorted() {
+		if child.isDir {
+			fmt.Fprintf(&builder, "<DIR>\t%s\n", child.name)
+		} else {
+			fmt.Fprintf(&builder, "%d\t%s\n", len(child.data), child.name)
+		}
+	}
+	return builder.String()
+}
+
+// Lists every file and folder under the node, with paths relative to it. base is the node's own relative path
+func (content *node) tree(base string) []Requests.TreeEntry {
+	entries := []Requests.TreeEntry{}
+	for _, child := range content.sorted() {
+		childPath := path.Join(base, child.name)
+		entry := Requests.TreeEntry{Path: childPath, IsDir: child.isDir, ModTime: child.modTime.Unix()}
+		if child.isDir {
+			entries = append(entries, entry)
+			entries = append(entries, child.tree(childPath)...)
+			continue
+		}
+		digest := sha256.Sum256(child.data)
+		entry.Size = uint64(len(child.data))
+		entry.Sha256 = hex.EncodeToString(digest[:])
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -10,5 +10,5 @@ require (
 	github.com/kazukousen/gouml v0.0.0-20200217144925-c881f0b0c32d // indirect
 	github.com/kr/logfmt v0.0.0-20210122060352-19f9bcb100e6 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/urfave/cli v1.22.14 // indirect
+	github.com/urfave/cli v1.22.14
 )